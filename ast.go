@@ -44,6 +44,14 @@ type Ident struct {
 	knownType
 	constValue
 	source envSource
+
+	// number of Parent scopes above the Env in which the identifier
+	// was found
+	depth int
+
+	// for fields and methods of a receiver scope created by WithReceiver
+	field []int
+	method reflect.Method
 }
 
 type Ellipsis struct {
@@ -59,6 +67,10 @@ type BasicLit struct {
 
 type FuncLit struct {
 	*ast.FuncLit
+	knownType
+
+	// Parameter and result names, "" for unnamed ones
+	params, results []string
 }
 
 type CompositeLit struct {
@@ -100,6 +112,14 @@ type SelectorExpr struct {
 
 	// the method index
 	method int
+
+	// if not nil, the method is promoted from the embedded interface
+	// field at this index
+	promoted []int
+
+	// if valid, this is a method expression such as T.Method,
+	// evaluating to a func taking the receiver as its first argument
+	methodExpr reflect.Value
 }
 
 type IndexExpr struct {
@@ -139,6 +159,9 @@ type CallExpr struct {
 
 	// Is an ellipsis expression used to unpack variadic arguments
 	argNEllipsis bool
+
+	// "byte" or "rune" for a type conversion spelled with the alias
+	spelling string
 }
 
 type StarExpr struct {
@@ -206,7 +229,6 @@ func (c constValue) Const() reflect.Value {
 }
 
 func (*BadExpr) KnownType() []reflect.Type      { return nil }
-func (*FuncLit) KnownType() []reflect.Type      { return nil }
 func (*KeyValueExpr) KnownType() []reflect.Type { return nil }
 
 func (*BadExpr) IsConst() bool        { return false }
@@ -242,13 +264,13 @@ func (*MapType) Const() reflect.Value        { return reflect.Value{} }
 func (*ChanType) Const() reflect.Value       { return reflect.Value{} }
 
 func (*BadExpr) setKnownType(t knownType)      { panic("eval: cannot set knownType of BadExpr") }
-func (*FuncLit) setKnownType(t knownType)      { panic("eval: cannot set knownType of FuncLit") }
 func (*KeyValueExpr) setKnownType(t knownType) { panic("eval: cannot set knownType of KeyValueExpr") }
 
 func (e *BasicLit) setKnownType(t knownType)       { e.knownType = t }
 func (e *BinaryExpr) setKnownType(t knownType)     { e.knownType = t }
 func (e *CallExpr) setKnownType(t knownType)       { e.knownType = t }
 func (e *Ellipsis) setKnownType(t knownType)       { e.knownType = t }
+func (e *FuncLit) setKnownType(t knownType)        { e.knownType = t }
 func (e *CompositeLit) setKnownType(t knownType)   { e.knownType = t }
 func (e *SelectorExpr) setKnownType(t knownType)   { e.knownType = t }
 func (e *Ident) setKnownType(t knownType)          { e.knownType = t }
@@ -277,7 +299,7 @@ func (badExpr *BadExpr) String() string {
 
 func (ident *Ident) String() string {
 	if ident.IsConst() {
-		return sprintConstValue(ident.KnownType()[0], ident.Const(), true)
+		return sprintConstExpr(ident, true)
 	}
 	return ident.Ident.String()
 }
@@ -292,7 +314,7 @@ func (ellipsis *Ellipsis) String() string {
 
 func (basicLit *BasicLit) String() string {
 	if basicLit.IsConst() {
-		return sprintConstValue(basicLit.KnownType()[0], basicLit.Const(), true)
+		return sprintConstExpr(basicLit, true)
 	}
 	return basicLit.Value
 }
@@ -324,7 +346,7 @@ func (lit *CompositeLit) String() string {
 
 func (parenExpr *ParenExpr) String() string {
 	if parenExpr.IsConst() {
-		return sprintConstValue(parenExpr.KnownType()[0], parenExpr.Const(), true)
+		return sprintConstExpr(parenExpr, true)
 	}
 	return fmt.Sprintf("(%v)", skipSuperfluousParens(parenExpr.X.(Expr)))
 }
@@ -359,9 +381,9 @@ func (call *CallExpr) String() string {
 			return s + ")"
 		} else {
 			if call.IsConst() {
-				return sprintConstValue(call.KnownType()[0], call.Const(), true)
+				return sprintConstExpr(call, true)
 			}
-			return fmt.Sprintf("%v(%v)", call.KnownType()[0], call.Args[0])
+			return fmt.Sprintf("%v(%v)", spellType(call.KnownType()[0], call.spelling), call.Args[0])
 		}
 	} else {
 		return fmt.Sprintf("%v()", call.Fun)
@@ -424,7 +446,7 @@ func (chanType *ChanType) String() string {
 // Returns a printable interface{} which replaces constant expressions with their constants
 func simplifyBinaryChildExpr(parent *BinaryExpr, expr Expr) interface{} {
         if expr.IsConst() {
-		return sprintConstValue(expr.KnownType()[0], expr.Const(), true)
+		return sprintConstExpr(expr, true)
 	}
 	expr = skipSuperfluousParens(expr)
 	if p, ok := expr.(*ParenExpr); ok {
@@ -436,14 +458,57 @@ func simplifyBinaryChildExpr(parent *BinaryExpr, expr Expr) interface{} {
 	return expr
 }
 
-func sprintConstValue(t reflect.Type, v reflect.Value, showZeroComponents bool) string {
-	if t == RuneType {
-		// When a rune constant is larger than 32 bits, it will be stored
-		// as a ConstRune, but should be printed as a Rune
-		if n, ok := v.Interface().(*ConstNumber); ok {
-			return fmt.Sprintf("rune(%v)", n.Value.StringShow0i(false))
+// byte and rune are aliases of uint8 and int32, but gc reports the type of
+// a conversion by the name it was spelled with, and so the type of
+// expressions computed from it. Returns "byte", "rune" or "" if the type of
+// expr is not spelled with an alias.
+func typeSpelling(expr Expr) string {
+	switch e := expr.(type) {
+	case *CallExpr:
+		return e.spelling
+	case *ParenExpr:
+		return typeSpelling(e.X.(Expr))
+	case *UnaryExpr:
+		switch e.Op {
+		case token.ADD, token.SUB, token.XOR:
+			return typeSpelling(e.X.(Expr))
 		}
+	case *BinaryExpr:
+		switch e.Op {
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ, token.LAND, token.LOR:
+			return ""
+		case token.SHL, token.SHR:
+			return typeSpelling(e.X.(Expr))
+		}
+		// The result has the type of the typed operand, the left if both are
+		x := e.X.(Expr)
+		if kt := x.KnownType(); len(kt) == 1 {
+			if _, ok := kt[0].(ConstType); !ok {
+				return typeSpelling(x)
+			}
+		}
+		return typeSpelling(e.Y.(Expr))
+	}
+	return ""
+}
+
+// Returns a printable t, which is shown as spelling if not ""
+func spellType(t reflect.Type, spelling string) interface{} {
+	if spelling != "" {
+		return spelling
+	}
+	return t
+}
+
+// Prints the constant expression expr, with its type if it is displayed
+func sprintConstExpr(expr Expr, showZeroComponents bool) string {
+	if spelling := typeSpelling(expr); spelling != "" {
+		return fmt.Sprintf("%s(%v)", spelling, sprintConstUntypedValue(expr.Const(), showZeroComponents))
 	}
+	return sprintConstValue(expr.KnownType()[0], expr.Const(), showZeroComponents)
+}
+
+func sprintConstValue(t reflect.Type, v reflect.Value, showZeroComponents bool) string {
 	if isTypeDisplayed(t) {
 		return fmt.Sprintf("%v(%v)", t, sprintConstUntypedValue(v, showZeroComponents))
 	} else {
@@ -1,8 +1,12 @@
 package eval
 
 import (
-	"errors"
+	"fmt"
+	"io"
+	"math"
 	"reflect"
+	"strconv"
+	"strings"
 )
 
 var (
@@ -27,6 +31,7 @@ var (
 	stringType reflect.Type = reflect.TypeOf(string(""))
 
 	emptyInterface reflect.Type = reflect.TypeOf(new(interface{})).Elem()
+	errorType reflect.Type = reflect.TypeOf(new(error)).Elem()
 
 	byteSlice reflect.Type = reflect.SliceOf(u8)
 )
@@ -51,11 +56,11 @@ var builtinTypes = map[string] reflect.Type{
 	"complex128": c128,
 
 	"bool": boolType,
-	"byte": ByteType,
-	"rune": RuneType,
+	"byte": u8,
+	"rune": i32,
 	"string": stringType,
 
-	"error": reflect.TypeOf(errors.New("")),
+	"error": errorType,
 }
 
 var builtinFuncs = map[string] reflect.Value{
@@ -66,9 +71,17 @@ var builtinFuncs = map[string] reflect.Value{
 	"cap": reflect.ValueOf(builtinCap),
 	"len": reflect.ValueOf(builtinLen),
 	"new": reflect.ValueOf(builtinNew),
+	"make": reflect.ValueOf(builtinMake),
 	"copy": reflect.ValueOf(builtinCopy),
 	"delete": reflect.ValueOf(builtinDelete),
 	"panic": reflect.ValueOf(builtinPanic),
+	"min": reflect.ValueOf(builtinMinMax),
+	"max": reflect.ValueOf(builtinMinMax),
+	"clear": reflect.ValueOf(builtinClear),
+	"close": reflect.ValueOf(builtinClose),
+	"print": reflect.ValueOf(builtinPrint),
+	"println": reflect.ValueOf(builtinPrint),
+	"recover": reflect.ValueOf(builtinRecover),
 }
 
 func builtinComplex(re, im reflect.Value) reflect.Value {
@@ -114,6 +127,19 @@ func builtinNew(t reflect.Type) reflect.Value {
 	return reflect.New(t)
 }
 
+func builtinMake(t reflect.Type, length, capacity int) reflect.Value {
+	switch t.Kind() {
+	case reflect.Slice:
+		return reflect.MakeSlice(t, length, capacity)
+	case reflect.Map:
+		return reflect.MakeMap(t)
+	case reflect.Chan:
+		return reflect.MakeChan(t, length)
+	default:
+		panic(dytc("make(bad type)"))
+	}
+}
+
 func builtinCopy(s, t reflect.Value) reflect.Value {
 	n := reflect.Copy(s, t)
 	return reflect.ValueOf(n)
@@ -127,3 +153,120 @@ func builtinDelete(m, k reflect.Value) reflect.Value {
 func builtinPanic(i reflect.Value) error {
 	return PanicUser(i)
 }
+
+// Min or max of xs, which must all have the same ordered type. As in Go,
+// floating point NaNs propagate and -0.0 is less than 0.0.
+func builtinMinMax(isMin bool, xs []reflect.Value) reflect.Value {
+	res := xs[0]
+	for _, x := range xs[1:] {
+		var less, greater bool
+		switch x.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			less, greater = x.Int() < res.Int(), x.Int() > res.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			less, greater = x.Uint() < res.Uint(), x.Uint() > res.Uint()
+		case reflect.Float32, reflect.Float64:
+			var f float64
+			if isMin {
+				f = math.Min(res.Float(), x.Float())
+			} else {
+				f = math.Max(res.Float(), x.Float())
+			}
+			res = reflect.ValueOf(f).Convert(res.Type())
+			continue
+		case reflect.String:
+			less, greater = x.String() < res.String(), x.String() > res.String()
+		}
+		if isMin && less || !isMin && greater {
+			res = x
+		}
+	}
+	return res
+}
+
+func builtinClear(v reflect.Value) {
+	v.Clear()
+}
+
+func builtinClose(c reflect.Value) (err error) {
+	if c.IsNil() {
+		return PanicCloseOfNilChannel{}
+	}
+	defer func() {
+		if recover() != nil {
+			err = PanicCloseOfClosedChannel{}
+		}
+	}()
+	c.Close()
+	return nil
+}
+
+func builtinPrint(w io.Writer, ln bool, xs []reflect.Value) {
+	var parts []string
+	for _, x := range xs {
+		parts = append(parts, printValue(x))
+	}
+	sep := ""
+	if ln {
+		sep = " "
+	}
+	out := strings.Join(parts, sep)
+	if ln {
+		out += "\n"
+	}
+	io.WriteString(w, out)
+}
+
+func builtinRecover() interface{} {
+	return nil
+}
+
+// Format v the way the Go runtime's print does
+func printValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return printFloat(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return "(" + printFloat(real(c)) + printFloat(imag(c)) + "i)"
+	case reflect.String:
+		return v.String()
+	case reflect.Slice:
+		return fmt.Sprintf("[%d/%d]%#x", v.Len(), v.Cap(), v.Pointer())
+	case reflect.Interface:
+		// The type or itab word and the data word of the interface
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		words := (*[2]uintptr)(p.UnsafePointer())
+		return fmt.Sprintf("(%#x,%#x)", words[0], words[1])
+	case reflect.Ptr, reflect.Chan, reflect.Map, reflect.Func, reflect.UnsafePointer:
+		return fmt.Sprintf("%#x", v.Pointer())
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// Floats are printed with a sign, six decimal places and a three digit
+// exponent, e.g. +1.500000e+000
+func printFloat(f float64) string {
+	switch {
+	case f != f:
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	s := strconv.FormatFloat(f, 'e', 6, 64)
+	if s[0] != '-' {
+		s = "+" + s
+	}
+	e := strings.IndexByte(s, 'e')
+	mant, exp := s[:e+2], s[e+2:]
+	return mant + strings.Repeat("0", 3-len(exp)) + exp
+}
@@ -31,24 +31,30 @@ func checkBasicLit(ctx *Ctx, lit *ast.BasicLit, env *Env) (*BasicLit, []error) {
 			return aexpr, nil
 		}
 	case token.INT:
-		if i, ok := NewConstInteger(lit.Value); !ok {
-			return aexpr, []error{ErrBadBasicLit{at(ctx, lit)}}
+		if i, ok := NewConstInteger(lit.Value); !ok || i.Overflows() {
+			// parser.ParseExpr() has rejected malformed literals, so this
+			// one is too large to represent
+			return aexpr, []error{ErrConstOverflow{at(ctx, lit)}}
 		} else {
 			aexpr.constValue = constValueOf(i)
 			aexpr.knownType = knownType{ConstInt}
 			return aexpr, nil
 		}
 	case token.FLOAT:
-		if f, ok := NewConstFloat(lit.Value); !ok {
-			return aexpr, []error{ErrBadBasicLit{at(ctx, lit)}}
+		if f, ok := NewConstFloat(lit.Value); !ok || f.Overflows() {
+			// parser.ParseExpr() has rejected malformed literals, so this
+			// one is too large to represent
+			return aexpr, []error{ErrConstOverflow{at(ctx, lit)}}
 		} else {
 			aexpr.constValue = constValueOf(f)
 			aexpr.knownType = knownType{ConstFloat}
 			return aexpr, nil
 		}
 	case token.IMAG:
-		if i, ok := NewConstImag(lit.Value); !ok {
-			return aexpr, []error{ErrBadBasicLit{at(ctx, lit)}}
+		if i, ok := NewConstImag(lit.Value); !ok || i.Overflows() {
+			// parser.ParseExpr() has rejected malformed literals, so this
+			// one is too large to represent
+			return aexpr, []error{ErrConstOverflow{at(ctx, lit)}}
 		} else {
 			aexpr.constValue = constValueOf(i)
 			aexpr.knownType = knownType{ConstComplex}
@@ -4,6 +4,7 @@ import (
 	"reflect"
 
 	"go/ast"
+	"go/constant"
 	"go/token"
 )
 
@@ -15,6 +16,12 @@ func checkBinaryExpr(ctx *Ctx, binary *ast.BinaryExpr, env *Env) (*BinaryExpr, [
 		return aexpr, errs
 	}
 	xt, yt := x.KnownType()[0], y.KnownType()[0]
+	operandErrs := len(errs)
+
+	if (binary.Op == token.SHL || binary.Op == token.SHR) && x.IsConst() && y.IsConst() {
+		errs = append(errs, checkConstShiftExpr(ctx, aexpr, x, y)...)
+		return aexpr, spellBinaryErrors(aexpr, errs, operandErrs)
+	}
 
 	xc, xuntyped := xt.(ConstType)
 	yc, yuntyped := yt.(ConstType)
@@ -87,21 +94,21 @@ func checkBinaryExpr(ctx *Ctx, binary *ast.BinaryExpr, env *Env) (*BinaryExpr, [
 				err := ErrInvalidBinaryOperation{at(ctx, aexpr)}
 				errs = append(errs, err)
 			} else {
-				err := ErrBadConstConversion{at(ctx, x), xt, yt, x.Const()}
+				err := ErrBadConstConversion{at(ctx, x), xt, yt, x.Const(), ""}
 				errs = append(errs, err)
 			}
 			// http://code.google.com/p/go/issues/detail?id=7206
 			if yk == reflect.Array || yk == reflect.Uintptr {
 				errs = append(errs, ErrUntypedNil{at(ctx, x)})
 			}
-			return aexpr, errs
+			return aexpr, spellBinaryErrors(aexpr, errs, operandErrs)
 		}
 
 		xk := xt.Kind()
 		var operandT reflect.Type
 		// Identical types are always valid, except non comparable structs
 		// and types with can only be compared to nil
-                if unhackType(xt) == unhackType(yt) {
+                if xt == yt {
 			if !comparableToNilOnly(xt) && (xk != reflect.Struct || isStructComparable(xt)) {
 				operandT = xt
 			}
@@ -154,7 +161,92 @@ func checkBinaryExpr(ctx *Ctx, binary *ast.BinaryExpr, env *Env) (*BinaryExpr, [
                         errs = append(errs, ErrInvalidBinaryOperation{at(ctx, aexpr)})
 		}
         }
-	return aexpr, errs
+	return aexpr, spellBinaryErrors(aexpr, errs, operandErrs)
+}
+
+// Folds the shift x << y or x >> y of constants. The count y must be a
+// non-negative integer. An untyped x becomes an untyped integer, or rune,
+// and a typed x must be an integer.
+func checkConstShiftExpr(ctx *Ctx, shift *BinaryExpr, x, y Expr) []error {
+	count, errs := checkConstShiftCount(ctx, y)
+
+	xt := x.KnownType()[0]
+	xn, ok := convertTypedToConstNumber(x.Const())
+	zt := ConstType(ConstInt)
+	if ct, untyped := xt.(ConstType); untyped {
+		if ct == ConstRune {
+			zt = ConstRune
+		}
+		if _, truncation := xn.Integer(); !ok || !ct.IsNumeric() || truncation || !xn.IsReal() {
+			ok = false
+		}
+	} else {
+		switch xt.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			ok = false
+		}
+	}
+	if !ok {
+		errs = append(errs, ErrInvalidShiftOperand{at(ctx, x)})
+	}
+	if errs != nil {
+		return errs
+	}
+
+	// Every larger count shifts out all constPrec bits
+	if count > constPrec {
+		if shift.Op == token.SHL && !xn.IsZero() {
+			return []error{ErrConstOverflow{at(ctx, shift)}}
+		}
+		count = constPrec + 1
+	}
+	z := &ConstNumber{constant.Shift(constant.ToInt(xn.Value), shift.Op, count), zt}
+	if z.Overflows() {
+		return []error{ErrConstOverflow{at(ctx, shift)}}
+	}
+
+	if _, untyped := xt.(ConstType); untyped {
+		shift.knownType = knownType{zt}
+		shift.constValue = constValueOf(z)
+		return nil
+	}
+	r, errs := promoteConstToTyped(ctx, zt, constValueOf(z), xt, shift)
+	if reflect.Value(r).IsValid() {
+		shift.knownType = knownType{xt}
+		shift.constValue = r
+	}
+	return errs
+}
+
+// The count of a constant shift, which must be a non-negative integer.
+// Counts larger than constPrec + 1 are returned as constPrec + 1.
+func checkConstShiftCount(ctx *Ctx, y Expr) (uint, []error) {
+	yt := y.KnownType()[0]
+	yn, ok := convertTypedToConstNumber(y.Const())
+	if ct, untyped := yt.(ConstType); untyped {
+		ok = ok && ct.IsNumeric() && yn.IsReal()
+	} else {
+		switch yt.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			ok = false
+		}
+	}
+	if !ok {
+		return 0, []error{ErrInvalidShiftCount{at(ctx, y)}}
+	}
+	count, truncation := yn.Integer()
+	if truncation {
+		return 0, []error{ErrInvalidShiftCount{at(ctx, y)}}
+	} else if count.Sign() < 0 {
+		return 0, []error{ErrNegativeShiftCount{at(ctx, y)}}
+	} else if count.BitLen() > 16 {
+		return constPrec + 1, nil
+	}
+	return uint(count.Uint64()), nil
 }
 
 // Evaluates a const binary Expr. May return a sensical constValue
@@ -182,7 +274,19 @@ func evalConstUntypedBinaryExpr(ctx *Ctx, constExpr *BinaryExpr, promotedType Co
 
 }
 
+// Fold x op y, rejecting results which exceed the limits of untyped
+// constants
 func evalConstBinaryNumericExpr(ctx *Ctx, constExpr *BinaryExpr, x, y *ConstNumber) (constValue, []error) {
+	c, errs := foldConstBinaryNumericExpr(ctx, constExpr, x, y)
+	if errs != nil || !reflect.Value(c).IsValid() {
+		return c, errs
+	} else if z, ok := reflect.Value(c).Interface().(*ConstNumber); ok && z.Overflows() {
+		return constValue{}, []error{ErrConstOverflow{at(ctx, constExpr)}}
+	}
+	return c, errs
+}
+
+func foldConstBinaryNumericExpr(ctx *Ctx, constExpr *BinaryExpr, x, y *ConstNumber) (constValue, []error) {
 	var errs []error
 
 	switch constExpr.Op {
@@ -193,12 +297,12 @@ func evalConstBinaryNumericExpr(ctx *Ctx, constExpr *BinaryExpr, x, y *ConstNumb
 	case token.MUL:
 		return constValueOf(new(ConstNumber).Mul(x, y)), nil
 	case token.QUO:
-		if y.Value.IsZero() {
+		if y.IsZero() {
 			return constValue{}, []error{ErrDivideByZero{at(ctx, constExpr.Y)}}
 		}
 		return constValueOf(new(ConstNumber).Quo(x, y)), nil
 	case token.REM:
-		if y.Value.IsZero() {
+		if y.IsZero() {
 			return constValue{}, []error{ErrDivideByZero{at(ctx, constExpr.Y)}}
 		} else if !(x.Type.IsIntegral() && y.Type.IsIntegral()) {
 			return constValue{}, []error{ErrInvalidBinaryOperation{at(ctx, constExpr)}}
@@ -224,16 +328,16 @@ func evalConstBinaryNumericExpr(ctx *Ctx, constExpr *BinaryExpr, x, y *ConstNumb
 		}
 
 	case token.EQL:
-		return constValueOf(x.Value.Equals(&y.Value)), nil
+		return constValueOf(x.Equals(y)), nil
 	case token.NEQ:
-		return constValueOf(!x.Value.Equals(&y.Value)), nil
+		return constValueOf(!x.Equals(y)), nil
 
 	case token.LEQ, token.GEQ, token.LSS, token.GTR:
 		var b bool
 		if !(x.Type.IsReal() && y.Type.IsReal()) {
 			return constValue{}, []error{ErrInvalidBinaryOperation{at(ctx, constExpr)}}
 		}
-		cmp := x.Value.Re.Cmp(&y.Value.Re)
+		cmp := x.Cmp(y)
 		switch constExpr.Op {
 		case token.NEQ:
 			b = cmp != 0
@@ -409,19 +513,10 @@ func evalConstTypedBinaryExpr(ctx *Ctx, binary *BinaryExpr, xexpr, yexpr Expr) (
 	xt := xexpr.KnownType()[0]
 	yt := yexpr.KnownType()[0]
 
-        // Check that the types are compatible, handling the special alias type for runes
-        // For the sake of error messages, for expressions involving int32 and rune, the
-        // resulting type is that of the left operand
-	var zt reflect.Type
-        if xt == yt {
-		zt = xt
-        } else if xt == RuneType && yt == RuneType.Type {
-                zt = RuneType
-        } else if yt == RuneType && xt == RuneType.Type {
-                zt = xt
-        } else {
+	if xt != yt {
 		return constValue{}, []error{ErrInvalidBinaryOperation{at(ctx, binary)}}
 	}
+	zt := xt
 
 	x, xok := convertTypedToConstNumber(xexpr.Const())
 	y, yok := convertTypedToConstNumber(yexpr.Const())
@@ -1,6 +1,8 @@
 package eval
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -539,3 +541,65 @@ func TestBasicCheckConstBinaryBoolBool(t *testing.T) {
 	expectConst(t, "true == false", env, false, ConstBool)
 	expectConst(t, "true != false", env, true, ConstBool)
 }
+
+// Untyped constants are limited to 512 bit integers, and floats with a
+// 512 bit mantissa and bounded exponent
+func TestBasicCheckConstOverflow(t *testing.T) {
+	env := makeEnv()
+	max := "0x" + strings.Repeat("f", 128)
+
+	expectConst(t, max+" == "+max, env, true, ConstBool)
+	expectConst(t, "1e-100000 != 0", env, true, ConstBool)
+	expectConst(t, "1e300 * 1e-300 == 1", env, true, ConstBool)
+	expectCheckError(t, max+" + 1", env, "constant addition overflow")
+	expectCheckError(t, "-"+max+" - 1", env, "constant subtraction overflow")
+	expectCheckError(t, max+" * 2", env, "constant multiplication overflow")
+	expectCheckError(t, max+"f", env, "constant overflow")
+	expectCheckError(t, "1e500000000 * 1e500000000", env, "constant multiplication overflow")
+	expectCheckError(t, "1e500000000i * 1e500000000", env, "constant multiplication overflow")
+	expectCheckError(t, "1e1000000000", env, "constant overflow")
+	expectCheckError(t, "1e1000000000i", env, "constant overflow")
+	expectCheckError(t, "1e400000000000000000000", env, "constant overflow")
+}
+
+func TestBasicCheckConstShift(t *testing.T) {
+	env := makeEnv()
+	max := "0x" + strings.Repeat("f", 128)
+
+	expectConst(t, "1 << 2", env, NewConstInt64(4), ConstInt)
+	expectConst(t, "1 >> 1", env, NewConstInt64(0), ConstInt)
+	expectConst(t, "-5 >> 1", env, NewConstInt64(-3), ConstInt)
+	expectConst(t, "2.0 << 3", env, NewConstInt64(16), ConstInt)
+	expectConst(t, "'a' << 1", env, NewConstRune(194), ConstRune)
+	expectConst(t, "1 << 100 >> 98", env, NewConstInt64(4), ConstInt)
+	expectConst(t, "0 << 100000", env, NewConstInt64(0), ConstInt)
+	expectConst(t, max+" >> 100000", env, NewConstInt64(0), ConstInt)
+	expectConst(t, "int8(1) << 6", env, int8(64), reflect.TypeOf(int8(0)))
+	expectConst(t, "uint(4) >> uint8(1)", env, uint(2), reflect.TypeOf(uint(0)))
+	expectCheckError(t, "1 << 511 << 1", env, "constant shift overflow")
+	expectCheckError(t, "1 << 100000", env, "constant shift overflow")
+	expectCheckError(t, "int8(1) << 7", env, "constant 128 overflows int8")
+	expectCheckError(t, "1 << -1", env, "invalid negative shift count: -1")
+	expectCheckError(t, "1 << 1.5", env, "invalid shift count 1.5")
+	expectCheckError(t, "1.5 << 1", env, "invalid operation: shifted operand 1.5 must be integer")
+	expectCheckError(t, "float64(1) << 1", env, "invalid operation: shifted operand float64(1) must be integer")
+}
+
+func TestBasicCheckConstFloatOverflow(t *testing.T) {
+	env := makeEnv()
+	e39 := "1" + strings.Repeat("0", 39)
+	e400 := "1" + strings.Repeat("0", 400)
+
+	expectConst(t, "float32(1e38)", env, float32(1e38), reflect.TypeOf(float32(0)))
+	expectConst(t, "complex64(1e38)", env, complex64(1e38), reflect.TypeOf(complex64(0)))
+	expectCheckError(t, "float32(1e39)", env, "constant "+e39+" overflows float32")
+	expectCheckError(t, "float32(-1e39)", env, "constant -"+e39+" overflows float32")
+	expectCheckError(t, "complex64(1e39)", env, "constant "+e39+" overflows complex64")
+	expectCheckError(t, "float64(1e400)", env, "constant "+e400+" overflows float64")
+	expectCheckError(t, "complex(1e400, 0)", env, "constant "+e400+" overflows float64")
+	expectCheckError(t, "float64(1<<1023)", env, "constant shift overflow")
+
+	if _, err := Eval("1e400", env); err == nil || err.Error() != "constant "+e400+" overflows float64" {
+		t.Fatalf("Expected 1e400 to overflow float64, got %v", err)
+	}
+}
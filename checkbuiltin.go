@@ -2,15 +2,17 @@ package eval
 
 import (
 	"reflect"
+	"strings"
 
 	"go/ast"
+	"go/constant"
 	"go/token"
 )
 
 func checkCallBuiltinExpr(ctx *Ctx, call *CallExpr, env *Env) (*CallExpr, []error, bool) {
 	var errs []error
 	ident, ok := call.Fun.(*ast.Ident)
-	if !ok {
+	if !ok || !isBuiltinFunc(ident.Name, env) {
 		return call, nil, false
 	}
 	switch ident.Name {
@@ -36,11 +38,35 @@ func checkCallBuiltinExpr(ctx *Ctx, call *CallExpr, env *Env) (*CallExpr, []erro
 		call, errs = checkBuiltinDeleteExpr(ctx, call, env)
 	case "panic":
 		call, errs = checkBuiltinPanicExpr(ctx, call, env)
+	case "min":
+		call, errs = checkBuiltinMinMaxExpr(ctx, call, env)
+	case "max":
+		call, errs = checkBuiltinMinMaxExpr(ctx, call, env)
+	case "clear":
+		call, errs = checkBuiltinClearExpr(ctx, call, env)
+	case "close":
+		call, errs = checkBuiltinCloseExpr(ctx, call, env)
+	case "print", "println":
+		call, errs = checkBuiltinPrintExpr(ctx, call, env)
+	case "recover":
+		call, errs = checkBuiltinRecoverExpr(ctx, call, env)
 	default:
 		return call, nil, false
 	}
 	call.Fun = &Ident{Ident: ident}
 	call.isBuiltin = true
+	if ctx.ReadOnly {
+		switch ident.Name {
+		case "copy", "delete", "clear", "close", "print", "println", "recover":
+			errs = append(errs, ErrSideEffect{at(ctx, call), ident.Name})
+		case "append":
+			// Appending to anything but a fresh slice may write into
+			// the spare capacity of a slice owned by the program.
+			if len(call.Args) == 0 || !isCompositeLit(call.Args[0].(Expr)) {
+				errs = append(errs, ErrSideEffect{at(ctx, call), ident.Name})
+			}
+		}
+	}
 	return call, errs, true
 }
 
@@ -176,7 +202,7 @@ func checkBuiltinComplex(ctx *Ctx, call *CallExpr, env *Env) (*CallExpr, []error
 			return call, errs
 		}
 	}
-	if unhackType(xt) == unhackType(yt) {
+	if xt == yt {
 		errs = append(errs, ErrBuiltinWrongArgType{at(ctx, x), call})
 	} else {
 		errs = append(errs, ErrBuiltinMismatchedArgs{at(ctx, call), xt, yt})
@@ -339,20 +365,19 @@ func checkBuiltinMake(ctx *Ctx, call *CallExpr, env *Env) (*CallExpr, []error) {
 	return call, errs
 }
 
-type callRecvWalker bool
-func (found *callRecvWalker) visit(expr Expr) bool {
-	if *found {
-		return false
-	}
-	if call, ok := expr.(*CallExpr); ok && !call.isTypeConversion {
-		*found = true
-		return false
-	}
-	if unary, ok := expr.(*UnaryExpr); ok && unary.Op == token.ARROW {
-		*found = true
-		return false
-	}
-	return true
+// Does expr contain a function call or channel receive. Such arrays do not
+// have a constant len or cap.
+func containsCallOrRecv(expr Expr) bool {
+	found := false
+	InspectExpr(expr, func(e Expr) bool {
+		if call, ok := e.(*CallExpr); ok && !call.isTypeConversion {
+			found = true
+		} else if unary, ok := e.(*UnaryExpr); ok && unary.Op == token.ARROW {
+			found = true
+		}
+		return !found
+	})
+	return found
 }
 
 func checkBuiltinLenCap(ctx *Ctx, call *CallExpr, env *Env, isLen bool) (*CallExpr, []error) {
@@ -394,9 +419,7 @@ func checkBuiltinLenCap(ctx *Ctx, call *CallExpr, env *Env, isLen bool) (*CallEx
 		}
 		fallthrough
 	case reflect.Array:
-		w := new(callRecvWalker)
-		walk(x, w)
-		if !*w {
+		if !containsCallOrRecv(x) {
 			call.constValue = constValueOf(xt.Len())
 		}
 	case reflect.String:
@@ -491,7 +514,7 @@ func checkBuiltinAppend(ctx *Ctx, call *CallExpr, env *Env) (*CallExpr, []error)
 						}
 					}
 				}
-				if !ok && unhackType(argI.KnownType()[0]) != unhackType(eltT) {
+				if !ok && argI.KnownType()[0] != eltT {
 					errs = append(errs, ErrBuiltinWrongArgType{at(ctx, argI), call})
 				}
 			}
@@ -555,7 +578,7 @@ func checkBuiltinCopyExpr(ctx *Ctx, call *CallExpr, env *Env) (*CallExpr, []erro
 			if xt != byteSlice {
 				errs = append(errs, ErrCopyArgsHaveDifferentEltTypes{at(ctx, call), xt, yt})
 			}
-		} else if unhackType(xt.Elem()) != unhackType(yt.Elem()) {
+		} else if xt.Elem() != yt.Elem() {
 			errs = append(errs, ErrCopyArgsHaveDifferentEltTypes{at(ctx, call), xt, yt})
 		}
 	}
@@ -638,6 +661,217 @@ func checkBuiltinPanicExpr(ctx *Ctx, call *CallExpr, env *Env) (*CallExpr, []err
 	return call, errs
 }
 
+func checkBuiltinMinMaxExpr(ctx *Ctx, call *CallExpr, env *Env) (*CallExpr, []error) {
+	var errs []error
+	if call.argNEllipsis = call.Ellipsis != token.NoPos; call.argNEllipsis {
+		errs = append(errs, ErrBuiltinInvalidEllipsis{at(ctx, call)})
+	}
+	if len(call.Args) == 0 {
+		return call, append(errs, ErrBuiltinWrongNumberOfArgs{at(ctx, call)})
+	}
+
+	// The first typed argument determines the type of the result. If all
+	// arguments are untyped, so is the result.
+	var t reflect.Type
+	for i := range call.Args {
+		x, moreErrs := CheckExpr(ctx, call.Args[i], env)
+		call.Args[i] = x
+		if moreErrs != nil {
+			errs = append(errs, moreErrs...)
+			if !x.IsConst() {
+				continue
+			}
+		}
+		if xt, err := expectSingleType(ctx, x.KnownType(), x); err != nil {
+			errs = append(errs, err)
+		} else if xt == ConstNil {
+			errs = append(errs, ErrUntypedNil{at(ctx, x)})
+		} else if !isOrderedType(xt) {
+			errs = append(errs, ErrBuiltinWrongArgType{at(ctx, x), call})
+		} else if _, ok := xt.(ConstType); !ok && t == nil {
+			t = xt
+		}
+	}
+	if errs != nil {
+		return call, errs
+	}
+
+	isMin := call.Fun.(*ast.Ident).Name == "min"
+	if t == nil {
+		return checkBuiltinMinMaxConst(ctx, call, isMin)
+	}
+
+	call.knownType = knownType{t}
+	isConst := true
+	xs := make([]reflect.Value, len(call.Args))
+	for i := range call.Args {
+		x := call.Args[i].(Expr)
+		xt := x.KnownType()[0]
+		if ct, ok := xt.(ConstType); ok {
+			cx, moreErrs := promoteConstToTyped(ctx, ct, constValue(x.Const()), t, x)
+			if moreErrs != nil {
+				errs = append(errs, moreErrs...)
+			} else if !reflect.Value(cx).IsValid() {
+				errs = append(errs, ErrBuiltinMismatchedArgs{at(ctx, call), t, xt})
+			}
+			xs[i] = reflect.Value(cx)
+		} else if xt != t {
+			errs = append(errs, ErrBuiltinMismatchedArgs{at(ctx, call), t, xt})
+		} else if x.IsConst() {
+			xs[i] = x.Const()
+		} else {
+			isConst = false
+		}
+	}
+	if isConst && errs == nil {
+		call.constValue = constValue(builtinMinMax(isMin, xs))
+	}
+	return call, errs
+}
+
+// Fold min or max of untyped constants. The result has the kind of the
+// last argument in the list int, rune, float.
+func checkBuiltinMinMaxConst(ctx *Ctx, call *CallExpr, isMin bool) (*CallExpr, []error) {
+	first := call.Args[0].(Expr)
+	ct := first.KnownType()[0].(ConstType)
+	best := first.Const()
+	for i := 1; i < len(call.Args); i += 1 {
+		x := call.Args[i].(Expr)
+		xct := x.KnownType()[0].(ConstType)
+		if (ct == ConstString) != (xct == ConstString) {
+			return call, []error{ErrBuiltinMismatchedArgs{at(ctx, call), ct, xct}}
+		}
+
+		var cmp int
+		if ct == ConstString {
+			cmp = strings.Compare(x.Const().String(), best.String())
+			ct = xct
+		} else {
+			xn := x.Const().Interface().(*ConstNumber)
+			bestn := best.Interface().(*ConstNumber)
+			cmp = xn.Cmp(bestn)
+			ct = promoteConstNumbers(ct, xct)
+		}
+		if isMin && cmp < 0 || !isMin && cmp > 0 {
+			best = x.Const()
+		}
+	}
+
+	call.knownType = knownType{ct}
+	if ct == ConstString {
+		call.constValue = constValue(best)
+	} else {
+		n := &ConstNumber{constant.Real(best.Interface().(*ConstNumber).Value), ct}
+		call.constValue = constValueOf(n)
+	}
+	return call, nil
+}
+
+func checkBuiltinClearExpr(ctx *Ctx, call *CallExpr, env *Env) (*CallExpr, []error) {
+	x, errs := checkBuiltinSingleArg(ctx, call, env)
+	if x == nil {
+		return call, errs
+	}
+	switch x.KnownType()[0].Kind() {
+	case reflect.Map, reflect.Slice:
+	default:
+		errs = append(errs, ErrBuiltinWrongArgType{at(ctx, x), call})
+	}
+	return call, errs
+}
+
+func checkBuiltinCloseExpr(ctx *Ctx, call *CallExpr, env *Env) (*CallExpr, []error) {
+	x, errs := checkBuiltinSingleArg(ctx, call, env)
+	if x == nil {
+		return call, errs
+	}
+	if xt := x.KnownType()[0]; xt.Kind() != reflect.Chan {
+		errs = append(errs, ErrBuiltinWrongArgType{at(ctx, x), call})
+	} else if xt.ChanDir() == reflect.RecvDir {
+		errs = append(errs, ErrCloseRecvOnlyChan{at(ctx, call)})
+	}
+	return call, errs
+}
+
+// Check the only argument of a builtin taking a single typed value. The
+// argument is returned only if its type is known and not nil.
+func checkBuiltinSingleArg(ctx *Ctx, call *CallExpr, env *Env) (Expr, []error) {
+	var errs []error
+	if call.argNEllipsis = call.Ellipsis != token.NoPos; call.argNEllipsis {
+		errs = append(errs, ErrBuiltinInvalidEllipsis{at(ctx, call)})
+	}
+	if len(call.Args) != 1 {
+		fakeCheckRemainingArgs(call, 0, env)
+		return nil, append(errs, ErrBuiltinWrongNumberOfArgs{at(ctx, call)})
+	}
+	x, moreErrs := CheckExpr(ctx, call.Args[0], env)
+	call.Args[0] = x
+	if moreErrs != nil {
+		errs = append(errs, moreErrs...)
+		if !x.IsConst() {
+			return nil, errs
+		}
+	}
+	if xt, err := expectSingleType(ctx, x.KnownType(), x); err != nil {
+		return nil, append(errs, err)
+	} else if xt == ConstNil {
+		return nil, append(errs, ErrUntypedNil{at(ctx, x)})
+	}
+	return x, errs
+}
+
+func checkBuiltinPrintExpr(ctx *Ctx, call *CallExpr, env *Env) (*CallExpr, []error) {
+	var errs []error
+	if call.argNEllipsis = call.Ellipsis != token.NoPos; call.argNEllipsis {
+		errs = append(errs, ErrBuiltinInvalidEllipsis{at(ctx, call)})
+	}
+	for i := range call.Args {
+		x, moreErrs := CheckExpr(ctx, call.Args[i], env)
+		call.Args[i] = x
+		if moreErrs != nil {
+			errs = append(errs, moreErrs...)
+			if !x.IsConst() {
+				continue
+			}
+		}
+		if xt, err := expectSingleType(ctx, x.KnownType(), x); err != nil {
+			errs = append(errs, err)
+		} else if xt == ConstNil {
+			errs = append(errs, ErrUntypedNil{at(ctx, x)})
+		} else if xt.Kind() == reflect.Struct || xt.Kind() == reflect.Array {
+			errs = append(errs, ErrBuiltinWrongArgType{at(ctx, x), call})
+		}
+	}
+	return call, errs
+}
+
+func checkBuiltinRecoverExpr(ctx *Ctx, call *CallExpr, env *Env) (*CallExpr, []error) {
+	call.knownType = knownType{emptyInterface}
+	var errs []error
+	if call.argNEllipsis = call.Ellipsis != token.NoPos; call.argNEllipsis {
+		errs = append(errs, ErrBuiltinInvalidEllipsis{at(ctx, call)})
+	}
+	if len(call.Args) != 0 {
+		fakeCheckRemainingArgs(call, 0, env)
+		errs = append(errs, ErrBuiltinWrongNumberOfArgs{at(ctx, call)})
+	}
+	return call, errs
+}
+
+// Can values of type t be ordered with < and >
+func isOrderedType(t reflect.Type) bool {
+	if ct, ok := t.(ConstType); ok {
+		return ct.IsReal() || ct == ConstString
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return true
+	}
+	return false
+}
+
 func fakeCheckRemainingArgs(call *CallExpr, from int, env *Env) {
 	for i := from; i < len(call.Args); i += 1 {
 		call.Args[i] = fakeCheckExpr(call.Args[i], env)
@@ -31,15 +31,27 @@ func checkCallExpr(ctx *Ctx, callExpr *ast.CallExpr, env *Env) (acall *CallExpr,
 			return acall, append(errs, moreErrs...)
 		}
 		acall.Fun = typ
-		return checkCallTypeExpr(ctx, acall, to, env)
+		acall, moreErrs = checkCallTypeExpr(ctx, acall, to, env)
+		if errs != nil {
+			// Errors converting an argument which failed to check
+			// only follow from its own
+			return acall, errs
+		}
+		return acall, moreErrs
 	} else {
-		return checkCallFunExpr(ctx, acall, env)
+		acall, moreErrs = checkCallFunExpr(ctx, acall, env)
+		return acall, append(errs, moreErrs...)
 	}
 }
 
 func checkCallTypeExpr(ctx *Ctx, call *CallExpr, to reflect.Type, env *Env) (acall *CallExpr, errs []error) {
 	call.knownType = []reflect.Type{to}
 	call.isTypeConversion = true
+	if ident, ok := call.Fun.(*Ident); ok {
+		if ident.Name == "byte" && to == u8 || ident.Name == "rune" && to == i32 {
+			call.spelling = ident.Name
+		}
+	}
 
 	if len(call.Args) != 1 {
 		return call, []error{ErrWrongNumberOfArgs{at(ctx, call), len(call.Args)}}
@@ -61,10 +73,11 @@ func checkCallTypeExpr(ctx *Ctx, call *CallExpr, to reflect.Type, env *Env) (aca
 		// ErrBadConversion The exception is if the conversion
 		// is from nil
 		v, errs := castConstToTyped(ctx, ct, constValue(arg.Const()), to, arg)
+		spellErrors(errs, to, call.spelling)
 		if ct != ConstNil {
 			if errs != nil {
 				if b, ok := errs[0].(ErrBadConstConversion); ok {
-					err := ErrBadConversion{b.ErrorContext, b.from, b.to, b.v}
+					err := ErrBadConversion{b.ErrorContext, b.from, b.to, b.v, b.spelling}
 					errs = append(errs, err)
 				}
 				// Some expr nodes will continue to generate
@@ -84,7 +97,7 @@ func checkCallTypeExpr(ctx *Ctx, call *CallExpr, to reflect.Type, env *Env) (aca
 			}
 			return call, nil
 		} else {
-			return call, []error{ErrBadConstConversion{at(ctx, call), from, to, reflect.Value{}}}
+			return call, []error{ErrBadConstConversion{at(ctx, call), from, to, reflect.Value{}, call.spelling}}
 		}
 	}
 }
@@ -106,6 +119,41 @@ func checkCallFunExpr(ctx *Ctx, call *CallExpr, env *Env) (*CallExpr, []error) {
 		return call, []error{ErrCallNonFuncType{at(ctx, fun)}}
 	}
 
+	switch f := skipSuperfluousParens(fun).(type) {
+	case *Ident:
+		callee := Callee{Name: f.Name}
+		if f.source == envFunc {
+			callee.Func = env.ancestor(f.depth).Funcs[f.Name]
+		} else if f.source == envMethod {
+			callee.Recv = f.method.Type.In(0)
+		}
+		errs = append(errs, checkPolicy(ctx, fun, callee)...)
+	case *SelectorExpr:
+		// Package functions and methods are checked by checkSelectorExpr
+		if f.pkgName != "" && f.Sel.source == envVar {
+			errs = append(errs, checkPolicy(ctx, fun, Callee{
+				Pkg:     f.pkgName,
+				PkgPath: pkgOf(f, env).Path,
+				Name:    f.Sel.Name,
+			})...)
+		} else if f.field != nil {
+			errs = append(errs, checkPolicy(ctx, fun, Callee{
+				Name:   f.Sel.Name,
+				Struct: fieldStruct(f.X.(Expr).KnownType()[0], f.field),
+			})...)
+		}
+	default:
+		errs = append(errs, checkPolicy(ctx, fun, Callee{})...)
+	}
+
+	if ctx.ReadOnly && !ctx.isPure(fun) {
+		what := "function call"
+		if sel, ok := skipSuperfluousParens(fun).(*SelectorExpr); ok && sel.pkgName == "" {
+			what = "method call"
+		}
+		errs = append(errs, ErrSideEffect{at(ctx, call), what})
+	}
+
 	call.knownType = make([]reflect.Type, ftype.NumOut())
 	for i := range call.knownType {
 		call.knownType[i] = ftype.Out(i)
@@ -119,9 +167,9 @@ func checkCallFunExpr(ctx *Ctx, call *CallExpr, env *Env) (*CallExpr, []error) {
 	// functions first.
 	if call.Args == nil {
 		if numIn == 0 || (variadic && numIn == 1) {
-			return call, nil
+			return call, errs
 		} else {
-			return call, []error{ErrWrongNumberOfArgs{at(ctx, call), len(call.Args)}}
+			return call, append(errs, ErrWrongNumberOfArgs{at(ctx, call), len(call.Args)})
 		}
 	}
 
@@ -236,3 +284,18 @@ func checkCallFunExpr(ctx *Ctx, call *CallExpr, env *Env) (*CallExpr, []error) {
 	}
 	return call, errs
 }
+
+// The struct type declaring the field at index, reached from t, which is a
+// struct or a pointer to one
+func fieldStruct(t reflect.Type, index []int) reflect.Type {
+	for _, i := range index[:len(index)-1] {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		t = t.Field(i).Type
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -214,7 +214,7 @@ func checkCompositeLitStruct(ctx *Ctx, lit *CompositeLit, t reflect.Type, env *E
 				kv.Key = fakeCheckExpr(kv.Key, env)
 				errs = append(errs, ErrInvalidStructField{at(ctx, kv.Key)})
 			} else if name := ident.Name; false {
-			} else if field, ok := t.FieldByName(name); !ok {
+			} else if field, ok := t.FieldByName(name); !ok || !isFieldVisible(field, env) {
 				errs = append(errs, ErrUnknownStructField{at(ctx, kv.Key), t, name})
 			} else {
 				if seen[name] {
@@ -237,6 +237,9 @@ func checkCompositeLitStruct(ctx *Ctx, lit *CompositeLit, t reflect.Type, env *E
 			if moreErrs != nil {
 				errs = append(errs, moreErrs...)
 			}
+			if !isFieldVisible(field, env) {
+				errs = append(errs, ErrImplicitUnexportedField{at(ctx, lit.Elts[i]), t, field.Name})
+			}
 			lit.fields = append(lit.fields, i)
 		}
 		if numFields != len(lit.Elts) {
@@ -312,3 +315,9 @@ func checkStructField(ctx *Ctx, expr ast.Expr, field reflect.StructField, env *E
 	}
 	return aexpr, errs
 }
+
+// Can a literal in env set field. As in Go, unexported fields may only be
+// set from the package declaring them, which for env is envPkgPath(env).
+func isFieldVisible(field reflect.StructField, env *Env) bool {
+	return field.PkgPath == "" || field.PkgPath == envPkgPath(env)
+}
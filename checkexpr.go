@@ -3,6 +3,8 @@ package eval
 import (
 	"errors"
 	"reflect"
+	"strconv"
+	"sync"
 	"go/ast"
 )
 
@@ -32,7 +34,7 @@ func CheckExpr(ctx *Ctx, expr ast.Expr, env *Env) (Expr, []error) {
 	case *ast.BasicLit:
 		return checkBasicLit(ctx, expr, env)
 	case *ast.FuncLit:
-		return &FuncLit{FuncLit: expr}, nil
+		return checkFuncLit(ctx, expr, env)
 	case *ast.CompositeLit:
 		return checkCompositeLit(ctx, expr, env)
 	case *ast.ParenExpr:
@@ -67,13 +69,28 @@ func checkType(ctx *Ctx, expr ast.Expr, env *Env) (Expr, reflect.Type, bool, []e
 	switch node := expr.(type) {
 	case *ast.Ident:
 		ident := &Ident{Ident: node}
-		if t, ok := env.Types[node.Name]; ok {
-			return ident, t, true, nil
-		} else if t, ok := builtinTypes[node.Name]; ok {
+		if scope, _ := env.lookupScope(node.Name); scope != nil {
+			if t, ok := scope.Types[node.Name]; ok {
+				return ident, t, true, nil
+			}
+			return ident, nil, false, []error{ErrUndefined{at(ctx, ident)}}
+		} else if t, ok := lookupUniverseType(node.Name, env); ok {
 			return ident, t, true, nil
 		} else {
 			return ident, nil, false, []error{ErrUndefined{at(ctx, ident)}}
 		}
+	case *ast.SelectorExpr:
+		// Types exported by a package
+		if ident, ok := node.X.(*ast.Ident); ok {
+			if pkg, _, ok := env.lookupPkg(ident.Name); ok {
+				if t, ok := pkg.Types[node.Sel.Name]; ok {
+					sel := &SelectorExpr{SelectorExpr: node, pkgName: ident.Name}
+					sel.X = &Ident{Ident: ident}
+					sel.Sel = &Ident{Ident: node.Sel}
+					return sel, t, true, nil
+				}
+			}
+		}
 	case *ast.StarExpr:
 		star := &StarExpr{StarExpr: node}
 		elem, elemT, isType, errs := checkType(ctx, node.X, env)
@@ -88,23 +105,35 @@ func checkType(ctx *Ctx, expr ast.Expr, env *Env) (Expr, reflect.Type, bool, []e
 		}
 	case *ast.ArrayType:
 		arrayT := &ArrayType{ArrayType: node}
-		if node.Len != nil {
-			return arrayT, nil, true, []error{errors.New("array types not implemented")}
-		} else {
-			elt, eltT, _, errs := checkType(ctx, node.Elt, env);
-			arrayT.Elt = elt
+		elt, eltT, _, errs := checkType(ctx, node.Elt, env)
+		arrayT.Elt = elt
+		if node.Len == nil {
 			if errs != nil {
 				return arrayT, nil, true, errs
-			} else {
-				return arrayT, reflect.SliceOf(unhackType(eltT)), true, nil
 			}
+			return arrayT, reflect.SliceOf(eltT), true, nil
+		} else if _, ok := node.Len.(*ast.Ellipsis); ok {
+			return arrayT, nil, true, append(errs, errors.New("[...] array types not implemented"))
+		}
+		length, n, ok, moreErrs := checkArrayIndex(ctx, node.Len, env)
+		arrayT.Len = length
+		if moreErrs != nil {
+			errs = append(errs, moreErrs...)
+		} else if !ok {
+			errs = append(errs, ErrInvalidArrayLen{at(ctx, length)})
+		}
+		if errs != nil {
+			return arrayT, nil, true, errs
 		}
+		return arrayT, reflect.ArrayOf(n, eltT), true, nil
 	case *ast.StructType:
 		structT := &StructType{StructType: node}
-		return structT, nil, true, []error{errors.New("struct types not implemented")}
+		t, errs := checkStructType(ctx, node, env)
+		return structT, t, true, errs
 	case *ast.FuncType:
 		funcT := &FuncType{FuncType: node}
-		return funcT, nil, true, []error{errors.New("func types not implemented")}
+		t, errs := checkFuncType(ctx, node, env)
+		return funcT, t, true, errs
 	case *ast.InterfaceType:
 		interfaceT := &InterfaceType{InterfaceType: node}
 		// Allow interface{}'s
@@ -125,7 +154,7 @@ func checkType(ctx *Ctx, expr ast.Expr, env *Env) (Expr, reflect.Type, bool, []e
 			errs = append(errs, moreErrs...)
 		}
 		if errs == nil {
-			return mapT, reflect.MapOf(unhackType(k), unhackType(v)), true, nil
+			return mapT, reflect.MapOf(k, v), true, nil
 		}
 		return mapT, nil, true, errs
 	case *ast.ChanType:
@@ -142,10 +171,135 @@ func checkType(ctx *Ctx, expr ast.Expr, env *Env) (Expr, reflect.Type, bool, []e
 			} else {
 				chanT.dir = reflect.BothDir
 			}
-			return chanT, reflect.ChanOf(chanT.dir, unhackType(valueT)), true, nil
+			return chanT, reflect.ChanOf(chanT.dir, valueT), true, nil
 		}
 	}
 	// Note this error should never be shown to the user. It is used to detect
 	// when a CallExpr is a type conversion
 	return nil, nil, false, []error{errors.New("Bad type")}
 }
+
+// The package path of code evaluated in env, "main" if env has none
+func envPkgPath(env *Env) string {
+	if env.Path == "" {
+		return "main"
+	}
+	return env.Path
+}
+
+// Struct types built by checkStructType. Only these may have their
+// unexported fields set through unsafe by composite literals, as their
+// fields belong to interpreted code.
+var declaredStructs sync.Map
+
+// Build the reflect.Type of a struct type literal. Unexported fields are
+// given the package path of env.
+func checkStructType(ctx *Ctx, node *ast.StructType, env *Env) (reflect.Type, []error) {
+	var fields []reflect.StructField
+	var errs []error
+	seen := map[string]bool{}
+	pkgPath := envPkgPath(env)
+	for _, field := range node.Fields.List {
+		_, t, _, moreErrs := checkType(ctx, field.Type, env)
+		if moreErrs != nil {
+			errs = append(errs, moreErrs...)
+			continue
+		}
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			s, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(s)
+		}
+		names := field.Names
+		anonymous := names == nil
+		if anonymous {
+			// The name of an embedded field is that of its type
+			name := embeddedFieldName(field.Type)
+			if name == nil {
+				errs = append(errs, ErrInvalidEmbeddedField{at(ctx, field.Type)})
+				continue
+			}
+			names = []*ast.Ident{name}
+		}
+		for _, name := range names {
+			if seen[name.Name] && name.Name != "_" {
+				errs = append(errs, ErrDuplicateField{at(ctx, name)})
+				continue
+			}
+			seen[name.Name] = true
+			f := reflect.StructField{Name: name.Name, Type: t, Tag: tag, Anonymous: anonymous}
+			if !name.IsExported() {
+				f.PkgPath = pkgPath
+			}
+			fields = append(fields, f)
+		}
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	t := reflect.StructOf(fields)
+	declaredStructs.Store(t, true)
+	return t, nil
+}
+
+func embeddedFieldName(t ast.Expr) *ast.Ident {
+	switch t := t.(type) {
+	case *ast.Ident:
+		return t
+	case *ast.SelectorExpr:
+		return t.Sel
+	case *ast.StarExpr:
+		if _, ok := t.X.(*ast.StarExpr); !ok {
+			return embeddedFieldName(t.X)
+		}
+	}
+	return nil
+}
+
+// Build the reflect.Type of a func type literal. The parser ensures that
+// only the final parameter is variadic.
+func checkFuncType(ctx *Ctx, node *ast.FuncType, env *Env) (reflect.Type, []error) {
+	in, errs := checkFieldTypes(ctx, node.Params, env)
+	out, moreErrs := checkFieldTypes(ctx, node.Results, env)
+	if errs = append(errs, moreErrs...); errs != nil {
+		return nil, errs
+	}
+	variadic := false
+	if params := node.Params.List; len(params) > 0 {
+		_, variadic = params[len(params)-1].Type.(*ast.Ellipsis)
+	}
+	return reflect.FuncOf(in, out, variadic), nil
+}
+
+// Types of the parameters or results in fields, one per name. A variadic
+// ...T parameter has type []T.
+func checkFieldTypes(ctx *Ctx, fields *ast.FieldList, env *Env) ([]reflect.Type, []error) {
+	var types []reflect.Type
+	var errs []error
+	if fields == nil {
+		return nil, nil
+	}
+	for _, field := range fields.List {
+		fieldT := field.Type
+		ellipsis, isVariadic := fieldT.(*ast.Ellipsis)
+		if isVariadic {
+			fieldT = ellipsis.Elt
+		}
+		_, t, _, moreErrs := checkType(ctx, fieldT, env)
+		if moreErrs != nil {
+			errs = append(errs, moreErrs...)
+			continue
+		}
+		if isVariadic {
+			t = reflect.SliceOf(t)
+		}
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i += 1 {
+			types = append(types, t)
+		}
+	}
+	return types, errs
+}
@@ -0,0 +1,25 @@
+package eval
+
+import (
+	"go/ast"
+)
+
+// Check a func literal, whose body is checked in env so that it may refer to
+// the variables of the enclosing scopes. In ReadOnly mode func literals are
+// rejected, as their bodies may have any side effect.
+func checkFuncLit(ctx *Ctx, lit *ast.FuncLit, env *Env) (*FuncLit, []error) {
+	alit := &FuncLit{FuncLit: lit}
+	if ctx.ReadOnly {
+		return alit, []error{ErrSideEffect{at(ctx, lit), "func literal"}}
+	}
+	t, errs := checkFuncType(ctx, lit.Type, env)
+	if errs != nil {
+		return alit, errs
+	}
+	alit.params, alit.results, errs = checkFuncBody(ctx, lit.Type, t, lit.Body, env)
+	if errs != nil {
+		return alit, errs
+	}
+	alit.knownType = knownType{t}
+	return alit, nil
+}
@@ -1,47 +1,66 @@
 package eval
 
 import (
-	"reflect"
 	"go/ast"
 )
 
-func checkIdent(ctx *Ctx, ident *ast.Ident, env *Env) (_ *Ident, errs []error) {
-	aexpr := &Ident{Ident: ident}
-	switch aexpr.Name {
-	case "nil":
-		aexpr.constValue = constValueOf(UntypedNil{})
-		aexpr.knownType = []reflect.Type{ConstNil}
+func checkIdent(ctx *Ctx, ident *ast.Ident, env *Env) (*Ident, []error) {
+	if scope, _ := env.lookupScope(ident.Name); scope != nil {
+		aexpr, ok := lookupIdent(ident, env)
+		if !ok {
+			return aexpr, []error{ErrUndefined{at(ctx, ident)}}
+		}
+		return aexpr, nil
+	}
 
-	case "true":
-		aexpr.constValue = constValueOf(true)
-		aexpr.knownType = []reflect.Type{ConstBool}
+	aexpr := &Ident{Ident: ident}
+	if v, ok := universe.Consts[aexpr.Name]; ok {
+		aexpr.constValue = constValue(v)
+		if v.Type() == boolType {
+			aexpr.knownType = knownType{ConstBool}
+		} else {
+			aexpr.knownType = knownType{ConstNil}
+		}
+		return aexpr, nil
+	} else if _, ok := universe.Funcs[aexpr.Name]; ok {
+		return aexpr, []error{ErrBuiltinNotCalled{at(ctx, ident)}}
+	}
+	return aexpr, []error{ErrUndefined{at(ctx, ident)}}
+}
 
-	case "false":
-		aexpr.constValue = constValueOf(false)
-		aexpr.knownType = []reflect.Type{ConstBool}
-        // TODO[crc] Remove this when builtin identifiers are handled
-        // in the general case. This complex method was added only to
-        // reable the binaryexpr tests
-        case "complex":
-		aexpr.knownType = []reflect.Type{reflect.TypeOf(complex128(0))}
-        default:
-                if v, ok := env.Vars[aexpr.Name]; ok {
-                        aexpr.knownType = knownType{v.Elem().Type()}
-			aexpr.source = envVar
-                } else if v, ok := env.Consts[aexpr.Name]; ok {
-                        if n, ok := v.Interface().(*ConstNumber); ok {
-                                aexpr.knownType = knownType{n.Type}
-                        } else {
-                                aexpr.knownType = knownType{v.Type()}
-                        }
-                        aexpr.constValue = constValue(v)
-			aexpr.source = envConst
-                } else if v, ok := env.Funcs[aexpr.Name]; ok {
-                        aexpr.knownType = knownType{v.Type()}
-			aexpr.source = envFunc
-                } else {
-                        errs = append(errs, ErrUndefined{at(ctx, ident)})
-                }
-        }
-	return aexpr, errs
+// Look up the value of ident in env and its parents, ignoring the
+// universe scope.
+func lookupIdent(ident *ast.Ident, env *Env) (*Ident, bool) {
+	aexpr := &Ident{Ident: ident}
+	env, aexpr.depth = env.lookupScope(ident.Name)
+	if env == nil {
+		return aexpr, false
+	} else if v, ok := env.Vars[aexpr.Name]; ok {
+		aexpr.knownType = knownType{v.Elem().Type()}
+		aexpr.source = envVar
+	} else if v, ok := env.Consts[aexpr.Name]; ok {
+		if n, ok := v.Interface().(*ConstNumber); ok {
+			aexpr.knownType = knownType{n.Type}
+		} else {
+			aexpr.knownType = knownType{v.Type()}
+		}
+		aexpr.constValue = constValue(v)
+		aexpr.source = envConst
+	} else if v, ok := env.Funcs[aexpr.Name]; ok {
+		aexpr.knownType = knownType{v.Type()}
+		aexpr.source = envFunc
+	} else if field, method, ok := env.lookupReceiver(aexpr.Name); ok {
+		if field.Index != nil {
+			aexpr.knownType = knownType{field.Type}
+			aexpr.field = field.Index
+			aexpr.source = envField
+		} else {
+			aexpr.knownType = knownType{env.receiver.Method(method.Index).Type()}
+			aexpr.method = method
+			aexpr.source = envMethod
+		}
+	} else {
+		return aexpr, false
+	}
+	return aexpr, true
 }
@@ -10,24 +10,40 @@ func checkSelectorExpr(ctx *Ctx, selector *ast.SelectorExpr, env *Env) (*Selecto
 
 	// First check if this is a package identifier
 	if ident, ok := selector.X.(*ast.Ident); ok {
-		if pkg, ok := env.Pkgs[ident.Name]; ok {
+		if pkg, depth, ok := env.lookupPkg(ident.Name); ok {
 			// Lookup this ident in the context of the package.
-			sel, errs := checkIdent(ctx, aexpr.SelectorExpr.Sel, pkg)
-			if len(errs) == 1 {
-				if undefined, ok := (errs[0]).(ErrUndefined); ok {
-					undefined.Node = aexpr
-					errs[0] = undefined
-				}
+			sel, ok := lookupIdent(aexpr.SelectorExpr.Sel, pkg)
+			var errs []error
+			if !ok {
+				errs = append(errs, ErrUndefined{at(ctx, aexpr)})
 			}
 			// This selector node is really a single identifier.
 			// Convey the type information to the parent.
 			aexpr.constValue = sel.constValue
 			aexpr.knownType = sel.knownType
 			aexpr.pkgName = ident.Name
-			aexpr.X = &Ident{Ident: ident}
+			aexpr.X = &Ident{Ident: ident, depth: depth}
 			aexpr.Sel = sel
+			if sel.source == envFunc {
+				errs = append(errs, checkPolicy(ctx, aexpr, Callee{
+					Pkg:     ident.Name,
+					PkgPath: pkg.Path,
+					Name:    sel.Name,
+					Func:    pkg.Funcs[sel.Name],
+				})...)
+			}
+			return aexpr, errs
+		}
+	}
+
+	// Method expressions, T.Method and (*T).Method
+	if typ, t, isType, errs := checkType(ctx, selector.X, env); isType {
+		aexpr.X = typ
+		aexpr.Sel = &Ident{Ident: selector.Sel}
+		if errs != nil {
 			return aexpr, errs
 		}
+		return checkMethodExpr(ctx, aexpr, t)
 	}
 
 	x, errs := CheckExpr(ctx, selector.X, env)
@@ -45,13 +61,20 @@ func checkSelectorExpr(ctx *Ctx, selector *ast.SelectorExpr, env *Env) (*Selecto
 	}
 
 	name := aexpr.Sel.Name
+	// Unexported fields of other packages may only be read as an option
+	checkField := func(field reflect.StructField) (*SelectorExpr, []error) {
+		aexpr.field = field.Index
+		aexpr.knownType = knownType{field.Type}
+		if !field.IsExported() && !ctx.Unexported && !ownsField(fieldStruct(t, field.Index), field, env) {
+			errs = append(errs, ErrUnexportedFieldSelector{at(ctx, aexpr)})
+		}
+		return aexpr, errs
+	}
 	// Check structs for field selectors
 	switch t.Kind() {
 	case reflect.Struct:
 		if field, ok := t.FieldByName(name); ok {
-			aexpr.field = field.Index
-			aexpr.knownType = knownType{field.Type}
-			return aexpr, errs
+			return checkField(field)
 		}
 	case reflect.Ptr:
 		// auto-indirect of *struct types
@@ -61,9 +84,7 @@ func checkSelectorExpr(ctx *Ctx, selector *ast.SelectorExpr, env *Env) (*Selecto
 		} else if t.Elem().Kind() != reflect.Struct {
 			break
 		} else if field, ok := t.Elem().FieldByName(name); ok {
-			aexpr.field = field.Index
-			aexpr.knownType = knownType{field.Type}
-			return aexpr, errs
+			return checkField(field)
 		}
 	}
 
@@ -74,7 +95,7 @@ func checkSelectorExpr(ctx *Ctx, selector *ast.SelectorExpr, env *Env) (*Selecto
 		if method, ok := t.MethodByName(name); ok {
 			aexpr.knownType = knownType{method.Type}
 			aexpr.method = method.Index
-			return aexpr, errs
+			return aexpr, append(errs, checkPolicy(ctx, aexpr, Callee{Name: name, Recv: t})...)
 		}
 	} else {
 		for i := 0; i < 2; i += 1 {
@@ -84,7 +105,12 @@ func checkSelectorExpr(ctx *Ctx, selector *ast.SelectorExpr, env *Env) (*Selecto
 				aexpr.knownType = knownType{bound.Type()}
 				aexpr.method = method.Index
 				aexpr.isPtrReceiver = i != 0
-				return aexpr, errs
+				if st := x.KnownType()[0]; st.Kind() == reflect.Struct {
+					aexpr.promoted = promotedFromInterface(st, name)
+				} else if st.Kind() == reflect.Ptr && st.Elem().Kind() == reflect.Struct {
+					aexpr.promoted = promotedFromInterface(st.Elem(), name)
+				}
+				return aexpr, append(errs, checkPolicy(ctx, aexpr, Callee{Name: name, Recv: t})...)
 			}
 			// Check for ptr receivers
 			t = reflect.PtrTo(t)
@@ -93,3 +119,100 @@ func checkSelectorExpr(ctx *Ctx, selector *ast.SelectorExpr, env *Env) (*Selecto
 
 	return aexpr, append(errs, ErrUndefinedFieldOrMethod{at(ctx, aexpr)})
 }
+
+func checkMethodExpr(ctx *Ctx, aexpr *SelectorExpr, t reflect.Type) (*SelectorExpr, []error) {
+	name := aexpr.Sel.Name
+	method, ok := t.MethodByName(name)
+	if !ok {
+		_, needsPtr := reflect.PtrTo(t).MethodByName(name)
+		return aexpr, []error{ErrUndefinedMethodExpr{at(ctx, aexpr), t, needsPtr}}
+	}
+
+	if t.Kind() == reflect.Interface {
+		// Interface methods have no Func, dispatch on the first argument.
+		in := []reflect.Type{t}
+		for i := 0; i < method.Type.NumIn(); i += 1 {
+			in = append(in, method.Type.In(i))
+		}
+		out := make([]reflect.Type, method.Type.NumOut())
+		for i := range out {
+			out[i] = method.Type.Out(i)
+		}
+		ftype := reflect.FuncOf(in, out, method.Type.IsVariadic())
+		index := method.Index
+		aexpr.methodExpr = reflect.MakeFunc(ftype, func(args []reflect.Value) []reflect.Value {
+			// The func value may be kept and called by a later
+			// evaluation, whose policy applies
+			policy := ctx.Policy
+			if c := currentHostCall(); c != nil {
+				policy = c.ctx.Policy
+			}
+			recv := args[0]
+			if recv.IsNil() {
+				panic(funcPanic{PanicInvalidDereference{}})
+			} else if policy != nil {
+				// Check the method actually dispatched to
+				callee := Callee{Name: name, Recv: recv.Elem().Type()}
+				if !policy.Allow(callee) {
+					panic(funcPanic{PanicCallDenied{callee}})
+				}
+			}
+			if ftype.IsVariadic() {
+				return recv.Method(index).CallSlice(args[1:])
+			}
+			return recv.Method(index).Call(args[1:])
+		})
+	} else {
+		aexpr.methodExpr = method.Func
+	}
+	aexpr.knownType = knownType{aexpr.methodExpr.Type()}
+	aexpr.method = method.Index
+	return aexpr, checkPolicy(ctx, aexpr, Callee{Name: name, Recv: t})
+}
+
+// The index of the embedded interface field which method name of t, a
+// struct or pointer to struct, is promoted from. Nil if the method is not
+// promoted from an interface. Embedded fields are searched breadth first,
+// as the shallowest one wins. Reflection cannot tell a method declared on
+// a struct from one it promotes, so a struct declaring a method of the
+// same name as an interface it embeds is treated as promoting it.
+func promotedFromInterface(t reflect.Type, name string) []int {
+	type embedded struct {
+		t     reflect.Type
+		index []int
+	}
+	visited := map[reflect.Type]bool{}
+	level := []embedded{{t, nil}}
+	for len(level) > 0 {
+		var next []embedded
+		for _, e := range level {
+			if visited[e.t] {
+				continue
+			}
+			visited[e.t] = true
+			for i := 0; i < e.t.NumField(); i += 1 {
+				field := e.t.Field(i)
+				if !field.Anonymous {
+					continue
+				}
+				index := append(append([]int(nil), e.index...), i)
+				ft := field.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Interface {
+					if _, ok := ft.MethodByName(name); ok {
+						return index
+					}
+				} else if ft.Kind() == reflect.Struct {
+					next = append(next, embedded{ft, index})
+				} else if _, ok := reflect.PtrTo(ft).MethodByName(name); ok {
+					// Declared on a concrete type
+					return nil
+				}
+			}
+		}
+		level = next
+	}
+	return nil
+}
@@ -36,6 +36,8 @@ func checkSliceExpr(ctx *Ctx, slice *ast.SliceExpr, env *Env) (*SliceExpr, []err
 		if t == ConstString {
 			// spec: ConstString[:] fields string
 			aexpr.knownType = knownType{stringType}
+		} else if t.Kind() == reflect.Array {
+			aexpr.knownType = knownType{reflect.SliceOf(t.Elem())}
 		} else {
 			aexpr.knownType = knownType(x.KnownType())
 		}
@@ -0,0 +1,802 @@
+package eval
+
+import (
+	"errors"
+	"reflect"
+
+	"go/ast"
+	"go/token"
+)
+
+// State of the function whose body is being checked
+type stmtCtx struct {
+	// Result types of the function, and whether they are named
+	results []reflect.Type
+	named   bool
+
+	// Enclosing statements which may be the target of a break or continue
+	targets []branchTarget
+
+	// Label of the statement about to be checked
+	label string
+}
+
+type branchTarget struct {
+	label  string
+	isLoop bool
+}
+
+// Check the statements of list in place, in the block scope env
+func checkStmtList(ctx *Ctx, list []ast.Stmt, env *Env, sc *stmtCtx) []error {
+	var errs []error
+	for i, stmt := range list {
+		s, moreErrs := checkStmt(ctx, stmt, env, sc)
+		list[i] = s
+		errs = append(errs, moreErrs...)
+	}
+	return errs
+}
+
+func checkStmt(ctx *Ctx, stmt ast.Stmt, env *Env, sc *stmtCtx) (ast.Stmt, []error) {
+	label := sc.label
+	sc.label = ""
+
+	switch s := stmt.(type) {
+	case *ast.EmptyStmt:
+		return s, nil
+	case *ast.ExprStmt:
+		return checkExprStmt(ctx, s, env)
+	case *ast.AssignStmt:
+		return checkAssignStmt(ctx, s, env)
+	case *ast.IncDecStmt:
+		return checkIncDecStmt(ctx, s, env)
+	case *ast.DeclStmt:
+		return checkDeclStmt(ctx, s, env)
+	case *ast.BlockStmt:
+		return &BlockStmt{s}, checkStmtList(ctx, s.List, NewScope(env), sc)
+	case *ast.ReturnStmt:
+		return checkReturnStmt(ctx, s, env, sc)
+	case *ast.IfStmt:
+		return checkIfStmt(ctx, s, env, sc)
+	case *ast.ForStmt:
+		return checkForStmt(ctx, s, label, env, sc)
+	case *ast.RangeStmt:
+		return checkRangeStmt(ctx, s, label, env, sc)
+	case *ast.SwitchStmt:
+		return checkSwitchStmt(ctx, s, label, env, sc)
+	case *ast.BranchStmt:
+		return checkBranchStmt(ctx, s, sc)
+	case *ast.LabeledStmt:
+		sc.label = s.Label.Name
+		return checkStmt(ctx, s.Stmt, env, sc)
+	case *ast.GoStmt:
+		call, errs := checkCallStmt(ctx, s.Call, "go", env)
+		return &GoStmt{s, call}, errs
+	case *ast.DeferStmt:
+		call, errs := checkCallStmt(ctx, s.Call, "defer", env)
+		return &DeferStmt{s, call}, errs
+	case *ast.SendStmt:
+		return checkSendStmt(ctx, s, env)
+	case *ast.SelectStmt:
+		return checkSelectStmt(ctx, s, label, env, sc)
+	case *ast.TypeSwitchStmt:
+		return s, []error{errors.New("type switches not implemented")}
+	default:
+		return s, []error{errors.New("statement not implemented")}
+	}
+}
+
+func checkExprStmt(ctx *Ctx, s *ast.ExprStmt, env *Env) (*ExprStmt, []error) {
+	x, errs := CheckExpr(ctx, s.X, env)
+	s.X = x
+	if errs != nil {
+		return &ExprStmt{s}, errs
+	}
+	switch x := skipSuperfluousParens(x).(type) {
+	case *CallExpr:
+		if !x.isTypeConversion {
+			return &ExprStmt{s}, nil
+		}
+	case *UnaryExpr:
+		if x.Op == token.ARROW {
+			return &ExprStmt{s}, nil
+		}
+	}
+	return &ExprStmt{s}, []error{ErrUnusedExpr{at(ctx, x)}}
+}
+
+// Check the call of a go or defer statement. As for expression statements,
+// builtins whose result would be discarded may not be called.
+func checkCallStmt(ctx *Ctx, call *ast.CallExpr, what string, env *Env) (*CallExpr, []error) {
+	x, errs := CheckExpr(ctx, call, env)
+	c, ok := x.(*CallExpr)
+	if errs != nil {
+		return c, errs
+	} else if !ok || c.isTypeConversion {
+		return c, []error{ErrNotCall{at(ctx, x), what}}
+	} else if c.isBuiltin {
+		switch c.Fun.(*Ident).Name {
+		case "close", "delete", "panic", "print", "println", "clear", "copy", "recover":
+		default:
+			return c, []error{ErrDiscardedResult{at(ctx, c), what}}
+		}
+	}
+	return c, nil
+}
+
+func checkDeclStmt(ctx *Ctx, s *ast.DeclStmt, env *Env) (*DeclStmt, []error) {
+	d := &DeclStmt{DeclStmt: s}
+	decl := s.Decl.(*ast.GenDecl)
+	switch decl.Tok {
+	case token.CONST, token.TYPE:
+		// Consts and types are fully known once checked, and are
+		// resolved by the nodes which refer to them.
+		if err := evalGenDecl(ctx, decl, env); err != nil {
+			if errs, ok := err.(CheckErrors); ok {
+				return d, errs
+			}
+			return d, []error{err}
+		}
+		return d, nil
+	}
+
+	var errs []error
+	for _, spec := range decl.Specs {
+		spec := spec.(*ast.ValueSpec)
+		vars, moreErrs := checkVarSpec(ctx, spec, env)
+		if moreErrs != nil {
+			errs = append(errs, moreErrs...)
+			continue
+		}
+		d.vars = append(d.vars, vars)
+		bindCheckVars(spec.Names, vars, env)
+	}
+	return d, errs
+}
+
+// Bind placeholders for variables declared in a block being checked, so
+// that later statements may refer to them. The placeholders are never
+// evaluated, each execution of the block binds its own.
+func bindCheckVars(names []*ast.Ident, vars []checkedVar, env *Env) {
+	for i, name := range names {
+		if name.Name != "_" {
+			env.unbind(name.Name)
+			env.Vars[name.Name] = reflect.New(vars[i].t)
+		}
+	}
+}
+
+func checkAssignStmt(ctx *Ctx, s *ast.AssignStmt, env *Env) (*AssignStmt, []error) {
+	a := &AssignStmt{AssignStmt: s}
+	switch s.Tok {
+	case token.DEFINE:
+		return a, checkDefine(ctx, a, env)
+	case token.ASSIGN:
+		errs := checkAssignLhs(ctx, a, env)
+		vars, moreErrs := checkAssignExprs(ctx, s, a.types, s.Rhs, true, env)
+		a.vars = vars
+		return a, append(errs, moreErrs...)
+	default:
+		if len(s.Lhs) != 1 || len(s.Rhs) != 1 {
+			return a, []error{ErrAssignCountMismatch{at(ctx, s), len(s.Lhs), len(s.Rhs)}}
+		}
+		return a, checkOpAssign(ctx, a, opAssignOp[s.Tok], env)
+	}
+}
+
+// The binary operator of each op= assignment
+var opAssignOp = map[token.Token]token.Token{
+	token.ADD_ASSIGN:     token.ADD,
+	token.SUB_ASSIGN:     token.SUB,
+	token.MUL_ASSIGN:     token.MUL,
+	token.QUO_ASSIGN:     token.QUO,
+	token.REM_ASSIGN:     token.REM,
+	token.AND_ASSIGN:     token.AND,
+	token.OR_ASSIGN:      token.OR,
+	token.XOR_ASSIGN:     token.XOR,
+	token.SHL_ASSIGN:     token.SHL,
+	token.SHR_ASSIGN:     token.SHR,
+	token.AND_NOT_ASSIGN: token.AND_NOT,
+}
+
+// x++ and x-- are checked as x += 1 and x -= 1
+func checkIncDecStmt(ctx *Ctx, s *ast.IncDecStmt, env *Env) (*AssignStmt, []error) {
+	// The 1 is placed at the second char of ++, so that x++ is the source
+	// of the x + 1 expression.
+	one := &ast.BasicLit{ValuePos: s.TokPos + 1, Kind: token.INT, Value: "1"}
+	assign := &ast.AssignStmt{Lhs: []ast.Expr{s.X}, TokPos: s.TokPos, Rhs: []ast.Expr{one}}
+	op := token.ADD
+	if assign.Tok = token.ADD_ASSIGN; s.Tok == token.DEC {
+		assign.Tok, op = token.SUB_ASSIGN, token.SUB
+	}
+	a := &AssignStmt{AssignStmt: assign}
+	return a, checkOpAssign(ctx, a, op, env)
+}
+
+// Check x op= y as x = x op y. The lhs is reparsed for the binary
+// expression, and so is evaluated twice. Index and call side effects in the
+// lhs therefore happen twice, unlike in Go.
+func checkOpAssign(ctx *Ctx, a *AssignStmt, op token.Token, env *Env) []error {
+	x := reparseExpr(ctx, a.Lhs[0])
+	if errs := checkAssignLhs(ctx, a, env); errs != nil {
+		return errs
+	} else if a.lhs[0] == nil {
+		return []error{ErrCannotAssign{at(ctx, a.Lhs[0])}}
+	}
+	binary := &ast.BinaryExpr{X: x, OpPos: a.TokPos, Op: op, Y: a.Rhs[0]}
+	opExpr, errs := CheckExpr(ctx, binary, env)
+	a.opExpr = opExpr
+	if errs != nil {
+		return errs
+	}
+	t := a.types[0]
+	if ok, errs := exprAssignableTo(ctx, opExpr, t); errs != nil {
+		return errs
+	} else if !ok {
+		return []error{ErrBadAssignType{at(ctx, opExpr), opExpr.KnownType()[0], t}}
+	}
+	return nil
+}
+
+// Check the lhs of an = assignment, each of which must be addressable, a
+// map index or _
+func checkAssignLhs(ctx *Ctx, a *AssignStmt, env *Env) []error {
+	var errs []error
+	a.lhs = make([]Expr, len(a.Lhs))
+	a.types = make([]reflect.Type, len(a.Lhs))
+	for i, lhs := range a.Lhs {
+		if isBlank(lhs) {
+			continue
+		}
+		x, moreErrs := CheckExpr(ctx, lhs, env)
+		a.Lhs[i] = x
+		if moreErrs != nil {
+			errs = append(errs, moreErrs...)
+			continue
+		}
+		t, err := expectSingleType(ctx, x.KnownType(), x)
+		if err != nil {
+			errs = append(errs, err)
+		} else if !isAssignable(x) {
+			errs = append(errs, ErrCannotAssign{at(ctx, x)})
+		} else {
+			a.lhs[i], a.types[i] = x, t
+		}
+	}
+	return errs
+}
+
+// Check x, y := a, b. At least one non-blank lhs must be new to the scope,
+// the others are assigned to.
+func checkDefine(ctx *Ctx, a *AssignStmt, env *Env) []error {
+	var errs []error
+	a.lhs = make([]Expr, len(a.Lhs))
+	a.types = make([]reflect.Type, len(a.Lhs))
+	isNew := make([]bool, len(a.Lhs))
+	seen := map[string]bool{}
+	anyNew := false
+	for i, lhs := range a.Lhs {
+		ident, ok := lhs.(*ast.Ident)
+		if !ok {
+			errs = append(errs, ErrNonNameDefine{at(ctx, lhs)})
+			continue
+		} else if ident.Name == "_" {
+			continue
+		} else if seen[ident.Name] {
+			errs = append(errs, ErrRepeatedDefine{at(ctx, lhs)})
+			continue
+		}
+		seen[ident.Name] = true
+		if _, ok := env.Vars[ident.Name]; !ok {
+			isNew[i], anyNew = true, true
+			continue
+		}
+		x, moreErrs := CheckExpr(ctx, lhs, env)
+		a.Lhs[i] = x
+		if moreErrs != nil {
+			errs = append(errs, moreErrs...)
+		} else {
+			a.lhs[i], a.types[i] = x, x.KnownType()[0]
+		}
+	}
+	if errs != nil {
+		return errs
+	} else if !anyNew {
+		return []error{ErrNoNewVars{at(ctx, a)}}
+	}
+
+	vars, errs := checkAssignExprs(ctx, a, a.types, a.Rhs, true, env)
+	a.vars = vars
+	if errs != nil {
+		return errs
+	}
+	for i, lhs := range a.Lhs {
+		if isNew[i] {
+			name := lhs.(*ast.Ident).Name
+			a.types[i] = vars[i].t
+			env.unbind(name)
+			env.Vars[name] = reflect.New(vars[i].t)
+		}
+	}
+	return nil
+}
+
+func isBlank(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "_"
+}
+
+// Can expr appear on the lhs of an assignment
+func isAssignable(expr Expr) bool {
+	if index, ok := skipSuperfluousParens(expr).(*IndexExpr); ok {
+		if index.X.(Expr).KnownType()[0].Kind() == reflect.Map {
+			return true
+		}
+	}
+	return isAddressable(expr)
+}
+
+// Is expr a map index, type assertion or receive, which has a comma ok form
+func isCommaOkExpr(expr Expr) bool {
+	switch x := skipSuperfluousParens(expr).(type) {
+	case *IndexExpr:
+		return x.X.(Expr).KnownType()[0].Kind() == reflect.Map
+	case *TypeAssertExpr:
+		return true
+	case *UnaryExpr:
+		return x.Op == token.ARROW
+	}
+	return false
+}
+
+func checkReturnStmt(ctx *Ctx, s *ast.ReturnStmt, env *Env, sc *stmtCtx) (*ReturnStmt, []error) {
+	r := &ReturnStmt{ReturnStmt: s}
+	if len(s.Results) == 0 {
+		if len(sc.results) != 0 && !sc.named {
+			return r, []error{ErrWrongResultCount{at(ctx, s), len(sc.results), 0}}
+		}
+		return r, nil
+	} else if len(s.Results) != len(sc.results) && (len(s.Results) != 1 || len(sc.results) < 2) {
+		return r, []error{ErrWrongResultCount{at(ctx, s), len(sc.results), len(s.Results)}}
+	}
+	vars, errs := checkAssignExprs(ctx, s, sc.results, s.Results, false, env)
+	r.vars = vars
+	return r, errs
+}
+
+func checkIfStmt(ctx *Ctx, s *ast.IfStmt, env *Env, sc *stmtCtx) (*IfStmt, []error) {
+	scope := NewScope(env)
+	var errs []error
+	if s.Init != nil {
+		var moreErrs []error
+		s.Init, moreErrs = checkStmt(ctx, s.Init, scope, sc)
+		errs = append(errs, moreErrs...)
+	}
+	cond, moreErrs := checkCondition(ctx, s.Cond, "if", scope)
+	s.Cond = cond
+	errs = append(errs, moreErrs...)
+	errs = append(errs, checkStmtList(ctx, s.Body.List, NewScope(scope), sc)...)
+	if s.Else != nil {
+		s.Else, moreErrs = checkStmt(ctx, s.Else, scope, sc)
+		errs = append(errs, moreErrs...)
+	}
+	return &IfStmt{s}, errs
+}
+
+// Check the condition of an if or for statement, which must be a boolean
+func checkCondition(ctx *Ctx, cond ast.Expr, what string, env *Env) (Expr, []error) {
+	x, errs := CheckExpr(ctx, cond, env)
+	if errs != nil {
+		return x, errs
+	}
+	t, err := expectSingleType(ctx, x.KnownType(), x)
+	if err != nil {
+		return x, []error{err}
+	} else if t.Kind() != reflect.Bool {
+		return x, []error{ErrNonBoolCondition{at(ctx, x), what}}
+	}
+	return x, nil
+}
+
+func checkForStmt(ctx *Ctx, s *ast.ForStmt, label string, env *Env, sc *stmtCtx) (*ForStmt, []error) {
+	scope := NewScope(env)
+	var errs, moreErrs []error
+	if s.Init != nil {
+		s.Init, moreErrs = checkStmt(ctx, s.Init, scope, sc)
+		errs = append(errs, moreErrs...)
+	}
+	if s.Cond != nil {
+		s.Cond, moreErrs = checkCondition(ctx, s.Cond, "for", scope)
+		errs = append(errs, moreErrs...)
+	}
+	if s.Post != nil {
+		s.Post, moreErrs = checkStmt(ctx, s.Post, scope, sc)
+		errs = append(errs, moreErrs...)
+	}
+	sc.targets = append(sc.targets, branchTarget{label, true})
+	errs = append(errs, checkStmtList(ctx, s.Body.List, NewScope(scope), sc)...)
+	sc.targets = sc.targets[:len(sc.targets)-1]
+	return &ForStmt{s, label}, errs
+}
+
+func checkRangeStmt(ctx *Ctx, s *ast.RangeStmt, label string, env *Env, sc *stmtCtx) (*RangeStmt, []error) {
+	r := &RangeStmt{RangeStmt: s, label: label}
+	x, errs := CheckExpr(ctx, s.X, env)
+	s.X = x
+	if errs != nil {
+		return r, errs
+	}
+	xT, err := expectSingleType(ctx, x.KnownType(), x)
+	if err != nil {
+		return r, []error{err}
+	}
+	if ct, ok := xT.(ConstType); ok && ct != ConstNil {
+		// Untyped consts can only be ranged over if they are integers
+		if ct.DefaultPromotion().Kind() != reflect.Int {
+			return r, []error{ErrCannotRange{at(ctx, x), xT}}
+		}
+		xT = intType
+		if _, errs := exprAssignableTo(ctx, x, xT); errs != nil {
+			return r, errs
+		}
+	} else if xT.Kind() == reflect.Ptr && xT.Elem().Kind() == reflect.Array {
+		xT = xT.Elem()
+	}
+
+	switch xT.Kind() {
+	case reflect.Array, reflect.Slice:
+		r.keyT, r.valueT = intType, xT.Elem()
+	case reflect.String:
+		r.keyT, r.valueT = intType, i32
+	case reflect.Map:
+		r.keyT, r.valueT = xT.Key(), xT.Elem()
+	case reflect.Chan:
+		if xT.ChanDir()&reflect.RecvDir == 0 {
+			return r, []error{ErrCannotRange{at(ctx, x), xT}}
+		}
+		r.keyT = xT.Elem()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		r.keyT = xT
+	default:
+		return r, []error{ErrCannotRange{at(ctx, x), xT}}
+	}
+	if s.Value != nil && r.valueT == nil {
+		return r, []error{ErrRangeTooManyVars{at(ctx, s.Value), xT}}
+	}
+
+	scope := NewScope(env)
+	vars := []ast.Expr{s.Key, s.Value}
+	types := []reflect.Type{r.keyT, r.valueT}
+	for i, v := range vars {
+		if v == nil || isBlank(v) {
+			continue
+		}
+		if s.Tok == token.DEFINE {
+			ident, ok := v.(*ast.Ident)
+			if !ok {
+				errs = append(errs, ErrNonNameDefine{at(ctx, v)})
+				continue
+			}
+			scope.unbind(ident.Name)
+			scope.Vars[ident.Name] = reflect.New(types[i])
+			continue
+		}
+		lhs, moreErrs := CheckExpr(ctx, v, env)
+		vars[i] = lhs
+		if moreErrs != nil {
+			errs = append(errs, moreErrs...)
+			continue
+		}
+		if t, err := expectSingleType(ctx, lhs.KnownType(), lhs); err != nil {
+			errs = append(errs, err)
+		} else if !isAssignable(lhs) {
+			errs = append(errs, ErrCannotAssign{at(ctx, lhs)})
+		} else if !typeAssignableTo(types[i], t) {
+			errs = append(errs, ErrBadAssignType{at(ctx, lhs), types[i], t})
+		}
+	}
+	if s.Tok == token.ASSIGN {
+		s.Key, s.Value = vars[0], vars[1]
+		r.key, _ = vars[0].(Expr)
+		r.value, _ = vars[1].(Expr)
+	}
+
+	sc.targets = append(sc.targets, branchTarget{label, true})
+	errs = append(errs, checkStmtList(ctx, s.Body.List, NewScope(scope), sc)...)
+	sc.targets = sc.targets[:len(sc.targets)-1]
+	return r, errs
+}
+
+func checkSwitchStmt(ctx *Ctx, s *ast.SwitchStmt, label string, env *Env, sc *stmtCtx) (*SwitchStmt, []error) {
+	sw := &SwitchStmt{SwitchStmt: s, label: label}
+	scope := NewScope(env)
+	var errs []error
+	if s.Init != nil {
+		var moreErrs []error
+		s.Init, moreErrs = checkStmt(ctx, s.Init, scope, sc)
+		errs = append(errs, moreErrs...)
+	}
+	if s.Tag != nil {
+		tag, moreErrs := CheckExpr(ctx, s.Tag, scope)
+		s.Tag = tag
+		if moreErrs != nil {
+			return sw, append(errs, moreErrs...)
+		}
+		t, err := expectSingleType(ctx, tag.KnownType(), tag)
+		if err != nil {
+			return sw, append(errs, err)
+		}
+		if ct, ok := t.(ConstType); ok {
+			if ct == ConstNil {
+				return sw, append(errs, ErrUntypedNil{at(ctx, tag)})
+			}
+			t = ct.DefaultPromotion()
+		}
+		sw.tagT = t
+	}
+
+	seenDefault := false
+	sc.targets = append(sc.targets, branchTarget{label, false})
+	for i, stmt := range s.Body.List {
+		clause := &caseClause{CaseClause: stmt.(*ast.CaseClause)}
+		sw.clauses = append(sw.clauses, clause)
+		if clause.List == nil {
+			if seenDefault {
+				errs = append(errs, ErrMultipleDefaults{at(ctx, clause), "switch"})
+			}
+			seenDefault = true
+		}
+		for j, value := range clause.List {
+			x, t, moreErrs := checkCaseValue(ctx, value, sw.tagT, scope)
+			clause.List[j] = x
+			clause.values = append(clause.values, x)
+			clause.types = append(clause.types, t)
+			errs = append(errs, moreErrs...)
+		}
+
+		clause.body = clause.Body
+		if n := len(clause.Body); n > 0 {
+			if b, ok := clause.Body[n-1].(*ast.BranchStmt); ok && b.Tok == token.FALLTHROUGH {
+				if i == len(s.Body.List)-1 {
+					errs = append(errs, ErrFallthroughFinalCase{at(ctx, b)})
+				}
+				clause.body, clause.fallsThrough = clause.Body[:n-1], true
+			}
+		}
+		errs = append(errs, checkStmtList(ctx, clause.body, NewScope(scope), sc)...)
+	}
+	sc.targets = sc.targets[:len(sc.targets)-1]
+	return sw, errs
+}
+
+// Check a case value against a switch tag of type tagT, or nil for a switch
+// without a tag. Returns the type in which the two are compared.
+func checkCaseValue(ctx *Ctx, value ast.Expr, tagT reflect.Type, env *Env) (Expr, reflect.Type, []error) {
+	if tagT == nil {
+		x, errs := CheckExpr(ctx, value, env)
+		if errs != nil {
+			return x, nil, errs
+		} else if t, err := expectSingleType(ctx, x.KnownType(), x); err != nil {
+			return x, nil, []error{err}
+		} else if t.Kind() != reflect.Bool {
+			return x, nil, []error{ErrInvalidCase{at(ctx, x), boolType}}
+		}
+		return x, boolType, nil
+	}
+	x, ok, errs := checkExprAssignableTo(ctx, value, tagT, env)
+	if errs != nil {
+		return x, nil, errs
+	}
+	xT := x.KnownType()[0]
+	if ok {
+		if xT != ConstNil && !isStaticTypeComparable(tagT) {
+			return x, nil, []error{ErrInvalidCase{at(ctx, x), tagT}}
+		}
+		return x, tagT, nil
+	} else if _, isConst := xT.(ConstType); !isConst && typeAssignableTo(tagT, xT) {
+		// Such as a concrete case value in a switch on an interface
+		return x, xT, nil
+	}
+	return x, nil, []error{ErrInvalidCase{at(ctx, x), tagT}}
+}
+
+func checkSendStmt(ctx *Ctx, s *ast.SendStmt, env *Env) (*SendStmt, []error) {
+	send := &SendStmt{SendStmt: s}
+	ch, errs := CheckExpr(ctx, s.Chan, env)
+	s.Chan = ch
+	if errs != nil {
+		return send, errs
+	}
+	t, err := expectSingleType(ctx, ch.KnownType(), ch)
+	if err != nil {
+		return send, []error{err}
+	} else if t.Kind() != reflect.Chan || t.ChanDir()&reflect.SendDir == 0 {
+		return send, []error{ErrInvalidSendTo{at(ctx, ch)}}
+	}
+	send.elemT = t.Elem()
+	x, ok, errs := checkExprAssignableTo(ctx, s.Value, send.elemT, env)
+	s.Value = x
+	if errs != nil {
+		return send, errs
+	} else if !ok {
+		return send, []error{ErrBadSendType{at(ctx, x), x.KnownType()[0], send.elemT}}
+	}
+	return send, nil
+}
+
+// Each clause of a select is checked in its own scope, which holds any
+// variables declared by a receive with :=.
+func checkSelectStmt(ctx *Ctx, s *ast.SelectStmt, label string, env *Env, sc *stmtCtx) (*SelectStmt, []error) {
+	sel := &SelectStmt{SelectStmt: s, label: label}
+	var errs []error
+	seenDefault := false
+	sc.targets = append(sc.targets, branchTarget{label, false})
+	for _, stmt := range s.Body.List {
+		clause := &commClause{CommClause: stmt.(*ast.CommClause)}
+		sel.clauses = append(sel.clauses, clause)
+		scope := NewScope(env)
+		switch comm := clause.Comm.(type) {
+		case nil:
+			if seenDefault {
+				errs = append(errs, ErrMultipleDefaults{at(ctx, clause), "select"})
+			}
+			seenDefault = true
+		case *ast.SendStmt:
+			send, moreErrs := checkSendStmt(ctx, comm, scope)
+			clause.Comm, clause.send = send, send
+			errs = append(errs, moreErrs...)
+		case *ast.ExprStmt:
+			if !isRecvExpr(comm.X) {
+				errs = append(errs, ErrInvalidSelectCase{at(ctx, comm)})
+				break
+			}
+			x, moreErrs := CheckExpr(ctx, comm.X, scope)
+			comm.X = x
+			if moreErrs != nil {
+				errs = append(errs, moreErrs...)
+				break
+			}
+			clause.recv = skipSuperfluousParens(x).(*UnaryExpr)
+		case *ast.AssignStmt:
+			if len(comm.Rhs) != 1 || !isRecvExpr(comm.Rhs[0]) {
+				errs = append(errs, ErrInvalidSelectCase{at(ctx, comm)})
+				break
+			}
+			a, moreErrs := checkAssignStmt(ctx, comm, scope)
+			clause.Comm = a
+			if moreErrs != nil {
+				errs = append(errs, moreErrs...)
+				break
+			}
+			clause.recv = skipSuperfluousParens(a.vars[0].expr).(*UnaryExpr)
+		default:
+			errs = append(errs, ErrInvalidSelectCase{at(ctx, comm)})
+		}
+		errs = append(errs, checkStmtList(ctx, clause.Body, scope, sc)...)
+	}
+	sc.targets = sc.targets[:len(sc.targets)-1]
+	return sel, errs
+}
+
+// Is the unchecked expr a receive, <-ch
+func isRecvExpr(expr ast.Expr) bool {
+	unary, ok := ast.Unparen(expr).(*ast.UnaryExpr)
+	return ok && unary.Op == token.ARROW
+}
+
+func checkBranchStmt(ctx *Ctx, s *ast.BranchStmt, sc *stmtCtx) (*BranchStmt, []error) {
+	b := &BranchStmt{s}
+	switch s.Tok {
+	case token.BREAK, token.CONTINUE:
+		for i := len(sc.targets) - 1; i >= 0; i -= 1 {
+			target := sc.targets[i]
+			if s.Label != nil && target.label != s.Label.Name {
+				continue
+			} else if s.Tok == token.CONTINUE && !target.isLoop {
+				if s.Label != nil {
+					break
+				}
+				continue
+			}
+			return b, nil
+		}
+		return b, []error{ErrInvalidBranch{at(ctx, s)}}
+	case token.GOTO:
+		return b, []error{errors.New("goto statements not implemented")}
+	default:
+		return b, []error{ErrMisplacedFallthrough{at(ctx, s)}}
+	}
+}
+
+// Is the statement list terminating, as defined by the Go spec, so that
+// the end of a function with results cannot be reached
+func isTerminatingList(list []ast.Stmt) bool {
+	return len(list) > 0 && isTerminating(list[len(list)-1])
+}
+
+func isTerminating(stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case *ReturnStmt:
+		return true
+	case *ExprStmt:
+		call, ok := s.X.(*CallExpr)
+		if !ok || !call.isBuiltin {
+			return false
+		}
+		ident, ok := call.Fun.(*Ident)
+		return ok && ident.Name == "panic"
+	case *BlockStmt:
+		return isTerminatingList(s.List)
+	case *IfStmt:
+		return s.Else != nil && isTerminatingList(s.Body.List) && isTerminating(s.Else)
+	case *ForStmt:
+		return s.Cond == nil && !hasBreak(s.Body.List, s.label, true)
+	case *SwitchStmt:
+		hasDefault := false
+		for _, clause := range s.clauses {
+			if clause.List == nil {
+				hasDefault = true
+			}
+			if !clause.fallsThrough && !isTerminatingList(clause.body) {
+				return false
+			} else if hasBreak(clause.body, s.label, true) {
+				return false
+			}
+		}
+		return hasDefault
+	case *SelectStmt:
+		for _, clause := range s.clauses {
+			if !isTerminatingList(clause.Body) || hasBreak(clause.Body, s.label, true) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Does list contain a break of the enclosing statement labelled label.
+// Unlabelled breaks count only if top, that is not nested in another
+// breakable statement.
+func hasBreak(list []ast.Stmt, label string, top bool) bool {
+	for _, stmt := range list {
+		switch s := stmt.(type) {
+		case *BranchStmt:
+			if s.Tok == token.BREAK {
+				if s.Label == nil && top || s.Label != nil && s.Label.Name == label {
+					return true
+				}
+			}
+		case *BlockStmt:
+			if hasBreak(s.List, label, top) {
+				return true
+			}
+		case *IfStmt:
+			if hasBreak(s.Body.List, label, top) || s.Else != nil && hasBreak([]ast.Stmt{s.Else}, label, top) {
+				return true
+			}
+		case *ForStmt:
+			if label != "" && hasBreak(s.Body.List, label, false) {
+				return true
+			}
+		case *RangeStmt:
+			if label != "" && hasBreak(s.Body.List, label, false) {
+				return true
+			}
+		case *SwitchStmt:
+			for _, clause := range s.clauses {
+				if label != "" && hasBreak(clause.body, label, false) {
+					return true
+				}
+			}
+		case *SelectStmt:
+			for _, clause := range s.clauses {
+				if label != "" && hasBreak(clause.Body, label, false) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
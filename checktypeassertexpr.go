@@ -26,7 +26,7 @@ func checkTypeAssertExpr(ctx *Ctx, assert *ast.TypeAssertExpr, env *Env) (*TypeA
 			errs = append(errs, moreErrs...)
 		} else {
 			aexpr.knownType = knownType{t}
-			if t.Kind() != reflect.Interface && !unhackType(t).Implements(xT) {
+			if t.Kind() != reflect.Interface && !t.Implements(xT) {
 				errs = append(errs, ErrImpossibleTypeAssert{at(ctx, aexpr)})
 			}
 		}
@@ -19,23 +19,29 @@ func checkUnaryExpr(ctx *Ctx, unary *ast.UnaryExpr, env *Env) (*UnaryExpr, []err
 				printableX := fakeCheckExpr(unary.X, env)
 				printableX.setKnownType(knownType{t})
 				errs = append(errs, ErrInvalidAddressOf{at(ctx, printableX)})
+			} else if ctx.ReadOnly && !isCompositeLit(x) {
+				errs = append(errs, ErrSideEffect{at(ctx, unary), "taking the address"})
 			}
 			t := x.KnownType()[0]
 			if ct, ok := t.(ConstType); ok {
 				if ct == ConstNil {
 					errs = append(errs, ErrUntypedNil{at(ctx, x)})
 				} else {
-					ptrT := reflect.PtrTo(unhackType(ct.DefaultPromotion()))
+					ptrT := reflect.PtrTo(ct.DefaultPromotion())
 					aexpr.knownType = knownType{ptrT}
 				}
 			} else {
-				ptrT := reflect.PtrTo(unhackType(t))
+				ptrT := reflect.PtrTo(t)
 				aexpr.knownType = knownType{ptrT}
 			}
 			aexpr.X = x
 		} else if unary.Op == token.ARROW { // <-
-			if (t.Kind() != reflect.Chan) || (t.ChanDir() | reflect.RecvDir == 0) {
+			if (t.Kind() != reflect.Chan) || (t.ChanDir()&reflect.RecvDir == 0) {
 				errs = append(errs, ErrInvalidRecvFrom{at(ctx, x)})
+			} else if ctx.ReadOnly {
+				errs = append(errs, ErrSideEffect{at(ctx, unary), "channel receive"})
+			} else {
+				aexpr.knownType = knownType{t.Elem()}
 			}
 		} else {
 			aexpr.X = x
@@ -83,7 +89,20 @@ func evalConstUnaryExpr(ctx *Ctx, unary *UnaryExpr, x Expr, xT ConstType) (const
 func evalConstTypedUnaryExpr(ctx *Ctx, unary *UnaryExpr, x Expr) (constValue, []error) {
 	t := x.KnownType()[0]
 	if xx, ok := convertTypedToConstNumber(x.Const()); ok {
-		zz, errs := evalConstUnaryNumericExpr(ctx, unary, xx)
+		var zz constValue
+		var errs []error
+		switch t.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if unary.Op == token.XOR {
+				// For unsigned operands ^x is m ^ x, with all bits of m set
+				mask := NewConstUint64(^uint64(0) >> uint(64-t.Bits()))
+				zz = constValueOf(mask.Xor(mask, xx))
+				break
+			}
+			fallthrough
+		default:
+			zz, errs = evalConstUnaryNumericExpr(ctx, unary, xx)
+		}
 		if !reflect.Value(zz).IsValid() {
 			return constValue{}, errs
 		}
@@ -100,7 +119,7 @@ func evalConstUnaryNumericExpr(ctx *Ctx, unary *UnaryExpr, x *ConstNumber) (cons
 	case token.ADD:
 		return constValueOf(x), nil
 	case token.SUB:
-		zero := &ConstNumber{Type: x.Type}
+		zero := NewConstInt64(0)
 		return constValueOf(zero.Sub(zero, x)), nil
 	case token.XOR:
 		if x.Type.IsIntegral() {
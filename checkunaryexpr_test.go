@@ -13,3 +13,13 @@ func TestCheckUnaryTypedExpr(t *testing.T) {
 
 	expectType(t, `-x`, env, reflect.TypeOf(-x))
 }
+
+func TestCheckUnaryUnsignedComplement(t *testing.T) {
+	env := makeEnv()
+
+	expectConst(t, `^uint(0)`, env, ^uint(0), reflect.TypeOf(uint(0)))
+	expectConst(t, `^uint8(1)`, env, uint8(254), reflect.TypeOf(uint8(0)))
+	expectConst(t, `^uint32(0)`, env, ^uint32(0), reflect.TypeOf(uint32(0)))
+	expectConst(t, `^int8(0)`, env, int8(-1), reflect.TypeOf(int8(0)))
+	expectCheckError(t, `-uint(1)`, env, "constant -1 overflows uint")
+}
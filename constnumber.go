@@ -1,78 +1,71 @@
 package eval
 
-import "strconv"
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
 
+	"go/constant"
+	"go/token"
+)
+
+// ConstNumber is an untyped numeric constant. Arithmetic is exact, as
+// implemented by go/constant, and Type records which of the untyped
+// numeric kinds the constant has.
 type ConstNumber struct {
-	Value BigComplex
-	Type ConstType
+	Value constant.Value
+	Type  ConstType
 }
 
+// Untyped integer constants may have at most constPrec bits, as in gc.
+// Other constants are limited by go/constant, which rounds values which
+// cannot be represented exactly in a reasonable size to a mantissa of
+// constPrec bits, and whose exponents are bounded.
+const constPrec = 512
+
 // Use with token.INT ast.BasicLit
 func NewConstInteger(i string) (*ConstNumber, bool) {
-	z := new(ConstNumber)
-	z.Type = ConstInt
-	z.Value.Re.Denom().SetInt64(1)
-	_, ok := z.Value.Re.Num().SetString(i, 0)
-	return z, ok
+	return newConstLiteral(i, token.INT, ConstInt)
 }
 
 // Use with token.FLOAT ast.BasicLit
 func NewConstFloat(r string) (*ConstNumber, bool) {
-	z := new(ConstNumber)
-	z.Type = ConstFloat
-	_, ok := z.Value.Re.SetString(r)
-	return z, ok
+	return newConstLiteral(r, token.FLOAT, ConstFloat)
 }
 
 // Use with token.IMAG ast.BasicLit
 func NewConstImag(i string) (*ConstNumber, bool) {
-	z := new(ConstNumber)
-	z.Type = ConstComplex
-	ok := i[len(i)-1] == 'i'
-	if ok {
-		_, ok = z.Value.Im.SetString(i[:len(i)-1])
-	}
-	return z, ok
+	return newConstLiteral(i, token.IMAG, ConstComplex)
+}
+
+// ok is false if lit is malformed, or too large to represent
+func newConstLiteral(lit string, tok token.Token, t ConstType) (*ConstNumber, bool) {
+	v := constant.MakeFromLiteral(lit, tok, 0)
+	return &ConstNumber{v, t}, v.Kind() != constant.Unknown
 }
 
 // Use with token.CHAR ast.BasicLit
 func NewConstRune(n rune) *ConstNumber {
-	z := new(ConstNumber)
-	z.Type = ConstRune
-	z.Value.Re.Denom().SetInt64(1)
-	z.Value.Re.Num().SetInt64(int64(n))
-	return z
+	return &ConstNumber{constant.MakeInt64(int64(n)), ConstRune}
 }
 
 func NewConstInt64(i int64) *ConstNumber {
-	z := new(ConstNumber)
-	z.Type = ConstInt
-	z.Value.Re.Denom().SetInt64(1)
-	z.Value.Re.Num().SetInt64(i)
-	return z
+	return &ConstNumber{constant.MakeInt64(i), ConstInt}
 }
 
 func NewConstUint64(u uint64) *ConstNumber {
-	z := new(ConstNumber)
-	z.Type = ConstInt
-	z.Value.Re.Denom().SetInt64(1)
-	z.Value.Re.Num().SetUint64(u)
-	return z
+	return &ConstNumber{constant.MakeUint64(u), ConstInt}
 }
 
 func NewConstFloat64(f float64) *ConstNumber {
-	z := new(ConstNumber)
-	z.Type = ConstFloat
-	z.Value.Re.SetFloat64(f)
-	return z
+	return &ConstNumber{constant.MakeFloat64(f), ConstFloat}
 }
 
 func NewConstComplex128(c complex128) *ConstNumber {
-	z := new(ConstNumber)
-	z.Type = ConstComplex
-	z.Value.Re.SetFloat64(real(c))
-	z.Value.Im.SetFloat64(imag(c))
-	return z
+	re := constant.MakeFloat64(real(c))
+	im := constant.MakeImag(constant.MakeFloat64(imag(c)))
+	return &ConstNumber{constant.BinaryOp(re, token.ADD, im), ConstComplex}
 }
 
 func (z *ConstNumber) String() string {
@@ -80,98 +73,273 @@ func (z *ConstNumber) String() string {
 }
 
 func (z *ConstNumber) StringShow0i(show0i bool) string {
-	if z.Type == ConstRune && z.Value.Re.Num().BitLen() <= 32 {
-		r, _, _ := z.Value.Int(32)
+	if z.Type == ConstRune && constant.BitLen(z.Value) <= 32 {
+		r, _, _ := z.Int(32)
 		return strconv.QuoteRuneToASCII(rune(r))
 	} else if z.Type == ConstComplex {
 		// Hack to show 0i instead of 0
-		if z.Value.IsZero() {
+		if z.IsZero() {
 			return "0i"
 		} else {
-			return z.Value.StringShow0i(show0i)
+			return formatConstComplex(z.Value, show0i)
 		}
 	} else {
-		return z.Value.StringShow0i(false)
+		return formatConstComplex(z.Value, false)
 	}
 }
 
-// Add two ConstNumbers, promoting the type automatically.
-func (z *ConstNumber) Add(x, y *ConstNumber) *ConstNumber {
+// Format v as re+imi, omitting a zero real part unless show0i, and a zero
+// imaginary part unless show0i. Integers are shown in full, other parts as
+// floats with 5 significant digits.
+func formatConstComplex(v constant.Value, show0i bool) string {
+	re, im := constant.Real(v), constant.Imag(v)
+	var s string
+	if constant.Sign(re) != 0 || show0i {
+		s += formatConstReal(re)
+	}
+	if constant.Sign(im) != 0 || show0i {
+		if s != "" {
+			s += "+"
+		}
+		s += formatConstReal(im) + "i"
+	}
+	if s == "" {
+		s = "0"
+	}
+	return s
+}
+
+func formatConstReal(x constant.Value) string {
+	if i := constant.ToInt(x); i.Kind() == constant.Int {
+		return i.ExactString()
+	}
+	f, _ := constant.Float64Val(x)
+	return fmt.Sprintf("%.5g", f)
+}
+
+// IsZero reports whether z is 0
+func (z *ConstNumber) IsZero() bool {
+	return constant.Sign(z.Value) == 0
+}
+
+// Equals reports whether z and other have the same value
+func (z *ConstNumber) Equals(other *ConstNumber) bool {
+	return constant.Compare(z.Value, token.EQL, other.Value)
+}
+
+// IsReal reports whether the imaginary part of z is 0
+func (z *ConstNumber) IsReal() bool {
+	return constant.Sign(constant.Imag(z.Value)) == 0
+}
+
+// Overflows reports whether z exceeds the limits of untyped constants
+func (z *ConstNumber) Overflows() bool {
+	if z.Value.Kind() == constant.Unknown {
+		return true
+	}
+	return z.Type.IsIntegral() && constant.BitLen(z.Value) > constPrec
+}
+
+// z.Integer() returns the real part of z, truncated towards zero to an
+// integer. truncation is true if the real part had a fractional part.
+func (z *ConstNumber) Integer() (_ *big.Int, truncation bool) {
+	re := constant.Real(z.Value)
+	if i := constant.ToInt(re); i.Kind() == constant.Int {
+		return constInt(i), false
+	}
+	switch x := constant.Val(constant.ToFloat(re)).(type) {
+	case *big.Rat:
+		return new(big.Int).Quo(x.Num(), x.Denom()), true
+	case *big.Float:
+		i, _ := x.Int(nil)
+		return i, true
+	}
+	panic("eval: impossible constant " + re.String())
+}
+
+// The value of the integer constant i as a big.Int
+func constInt(i constant.Value) *big.Int {
+	switch x := constant.Val(i).(type) {
+	case int64:
+		return big.NewInt(x)
+	case *big.Int:
+		return new(big.Int).Set(x)
+	}
+	panic("eval: impossible constant " + i.String())
+}
+
+// z.Int() returns a representation of z, truncated to be an int of
+// length bits.  Valid values for bits are 8, 16, 32, 64. Result is
+// otherwise undefined If a truncation occurs, the decimal part is
+// dropped and the conversion continues as usual. truncation will be
+// true If an overflow occurs, the result is equivelant to a cast of
+// the form int32(x). overflow will be true.
+func (z *ConstNumber) Int(bits int) (_ int64, truncation, overflow bool) {
+	var res *big.Int
+	res, truncation = z.Integer()
+
+	// Numerator must fit in bits - 1, with 1 bit left for sign.
+	// An exceptional case when only the signed bit is set.
+	if overflow = res.BitLen() > bits-1; overflow {
+		var mask uint64 = ^uint64(0) >> uint(64-bits)
+		if res.BitLen() == bits && res.Sign() < 0 {
+			// To detect the edge of minus 0b1000..., add one
+			// to get 0b0ff... and recount the bits
+			plus1 := new(big.Int).Add(res, big.NewInt(1))
+			if plus1.BitLen() < bits {
+				return res.Int64(), truncation, false
+			}
+		}
+		res.And(res, new(big.Int).SetUint64(mask))
+	}
+	return res.Int64(), truncation, overflow
+}
+
+// z.Uint() returns a representation of z truncated to be a uint of
+// length bits.  Valid values for bits are 0, 8, 16, 32, 64. The
+// returned result is otherwise undefined. If a truncation occurs, the
+// decimal part is dropped and the conversion continues as
+// usual. Return values truncation and overflow will be true if an
+// overflow occurs. The result is equivelant to a cast of the form
+// uint32(x).
+func (z *ConstNumber) Uint(bits int) (_ uint64, truncation, overflow bool) {
+	var res *big.Int
+	res, truncation = z.Integer()
+
+	var mask uint64 = ^uint64(0) >> uint(64-bits)
+	if overflow = res.BitLen() > bits; overflow {
+		res = new(big.Int).And(res, new(big.Int).SetUint64(mask))
+	}
+
+	r := res.Uint64()
+	if res.Sign() < 0 {
+		overflow = true
+		r = (^r + 1) & mask
+	}
+	return r, truncation, overflow
+}
+
+// z.Float64() returns a representation of z truncated to a float64 If
+// a truncation from a complex occurs. The imaginary part is dropped
+// and the conversion continues as usual. return value truncation will
+// be true exact will be true if the conversion was completed without
+// loss of precision.
+func (z *ConstNumber) Float64() (f float64, truncation, exact bool) {
+	f, exact = constant.Float64Val(constant.Real(z.Value))
+	return f, !z.IsReal(), exact
+}
+
+// z.Float(bits) returns z rounded to a float of the given size, 32 or
+// 64, as a float64. As with Float64, the imaginary part is dropped and
+// return value truncation will be true. overflow will be true if z is too
+// large for a float of that size.
+func (z *ConstNumber) Float(bits int) (f float64, truncation, overflow bool) {
+	f = floatVal(constant.Real(z.Value), bits)
+	return f, !z.IsReal(), math.IsInf(f, 0)
+}
+
+// z.Complex(bits) returns z rounded to a complex of the given size, 64 or
+// 128, as a complex128. overflow will be true if either part is too large
+// for the floats of a complex of that size.
+func (z *ConstNumber) Complex(bits int) (c complex128, overflow bool) {
+	r := floatVal(constant.Real(z.Value), bits/2)
+	i := floatVal(constant.Imag(z.Value), bits/2)
+	return complex(r, i), math.IsInf(r, 0) || math.IsInf(i, 0)
+}
+
+func floatVal(x constant.Value, bits int) float64 {
+	if bits == 32 {
+		f, _ := constant.Float32Val(x)
+		return float64(f)
+	}
+	f, _ := constant.Float64Val(x)
+	return f
+}
+
+// z.Complex128() returns a complex128 representation of z. Return value
+// exact will be true if the conversion was completed without loss of
+// precision.
+func (z *ConstNumber) Complex128() (_ complex128, exact bool) {
+	r, re := constant.Float64Val(constant.Real(z.Value))
+	i, ie := constant.Float64Val(constant.Imag(z.Value))
+	return complex(r, i), re && ie
+}
+
+// Cmp compares the real parts of z and other, returning -1, 0 or 1
+func (z *ConstNumber) Cmp(other *ConstNumber) int {
+	x, y := constant.Real(z.Value), constant.Real(other.Value)
+	if constant.Compare(x, token.LSS, y) {
+		return -1
+	} else if constant.Compare(x, token.GTR, y) {
+		return 1
+	}
+	return 0
+}
+
+func (z *ConstNumber) binaryOp(x *ConstNumber, op token.Token, y *ConstNumber) *ConstNumber {
 	z.Type = promoteConstNumbers(x.Type, y.Type)
-	z.Value.Add(&x.Value, &y.Value)
+	z.Value = constant.BinaryOp(x.Value, op, y.Value)
 	return z
 }
 
+// Add two ConstNumbers, promoting the type automatically.
+func (z *ConstNumber) Add(x, y *ConstNumber) *ConstNumber {
+	return z.binaryOp(x, token.ADD, y)
+}
+
 // z.Sub() subtracts two ConstNumbers, promoting the type
 // automatically.
 func (z *ConstNumber) Sub(x, y *ConstNumber) *ConstNumber {
-	z.Type = promoteConstNumbers(x.Type, y.Type)
-	z.Value.Sub(&x.Value, &y.Value)
-	return z
+	return z.binaryOp(x, token.SUB, y)
 }
 
 // z.Mul multiplies two ConstNumbers, promoting the type
 // automatically.
 func (z *ConstNumber) Mul(x, y *ConstNumber) *ConstNumber {
-	z.Type = promoteConstNumbers(x.Type, y.Type)
-	z.Value.Mul(&x.Value, &y.Value)
-	return z
+	return z.binaryOp(x, token.MUL, y)
 }
 
 // z.Quo divides two ConstNumbers, promoting the type
 // automatically. If both operands are of ConstInt, then integer
 // division is performed.
 func (z *ConstNumber) Quo(x, y *ConstNumber) *ConstNumber {
-	z.Type = promoteConstNumbers(x.Type, y.Type)
-	if z.Type.IsIntegral() {
-		z.Value.Re.Num().Div(x.Value.Re.Num(), y.Value.Re.Num())
-	} else {
-		z.Value.Quo(&x.Value, &y.Value)
+	if promoteConstNumbers(x.Type, y.Type).IsIntegral() {
+		return z.binaryOp(x, token.QUO_ASSIGN, y)
 	}
-	return z
+	return z.binaryOp(x, token.QUO, y)
 }
 
 // z.Rem computes remainder of two ConstNumbers, promoting the type
 // automatically. The result is undefined if both x and y are not
 // integral types.
 func (z *ConstNumber) Rem(x, y *ConstNumber) *ConstNumber {
-	z.Type = promoteConstNumbers(x.Type, y.Type)
-	z.Value.Re.Num().Rem(x.Value.Re.Num(), y.Value.Re.Num())
-	return z
+	return z.binaryOp(x, token.REM, y)
 }
 
 // z.And compute logical "and" of two ConstNumbers, promoting the type
 // automatically.  The result is undefined if both x and y are not
 // integral types.
 func (z *ConstNumber) And(x, y *ConstNumber) *ConstNumber {
-	z.Type = promoteConstNumbers(x.Type, y.Type)
-	z.Value.Re.Num().And(x.Value.Re.Num(), y.Value.Re.Num())
-	return z
+	return z.binaryOp(x, token.AND, y)
 }
 
 // z.Or computes the logical "o"r of two ConstNumbers, promoting the
 // type automatically. The result is undefined if both x and y are not
 // integral types.
 func (z *ConstNumber) Or(x, y *ConstNumber) *ConstNumber {
-	z.Type = promoteConstNumbers(x.Type, y.Type)
-	z.Value.Re.Num().Or(x.Value.Re.Num(), y.Value.Re.Num())
-	return z
+	return z.binaryOp(x, token.OR, y)
 }
 
 // z.Xor computes the exclusive "or" of two ConstNumbers, promoting
 // the type automatically. The result is undefined if both x and y are
 // not integral types.
 func (z *ConstNumber) Xor(x, y *ConstNumber) *ConstNumber {
-	z.Type = promoteConstNumbers(x.Type, y.Type)
-	z.Value.Re.Num().Xor(x.Value.Re.Num(), y.Value.Re.Num())
-	return z
+	return z.binaryOp(x, token.XOR, y)
 }
 
 // z.AndNot computes "and not" of two ConstNumbers, promoting the type
 // automatically.  The result is undefined if both x and y are not
 // integral types.
 func (z *ConstNumber) AndNot(x, y *ConstNumber) *ConstNumber {
-	z.Type = promoteConstNumbers(x.Type, y.Type)
-	z.Value.Re.Num().AndNot(x.Value.Re.Num(), y.Value.Re.Num())
-	return z
+	return z.binaryOp(x, token.AND_NOT, y)
 }
@@ -1,7 +1,10 @@
 package eval
 
 import (
+	"math/big"
 	"testing"
+
+	"go/constant"
 )
 
 func TestIntOverflows(t *testing.T) {
@@ -52,7 +55,7 @@ func TestUintOverflows(t *testing.T) {
 	expectUintOverflow(t, 64, newBigInt("-0xfffffffffffffffffe"), 0x0000000000000002)
 }
 
-func expectIntOverflow(t *testing.T, bits int, c *BigComplex, expected int64) {
+func expectIntOverflow(t *testing.T, bits int, c *ConstNumber, expected int64) {
 	if result, truncation, overflow := c.Int(bits); truncation {
 		t.Fatalf("Unexpected truncation")
 	} else if !overflow {
@@ -62,7 +65,7 @@ func expectIntOverflow(t *testing.T, bits int, c *BigComplex, expected int64) {
 	}
 }
 
-func expectUintOverflow(t *testing.T, bits int, c *BigComplex, expected uint64) {
+func expectUintOverflow(t *testing.T, bits int, c *ConstNumber, expected uint64) {
 	if result, truncation, overflow := c.Uint(bits); truncation {
 		t.Fatalf("Unexpected truncation")
 	} else if !overflow {
@@ -72,12 +75,10 @@ func expectUintOverflow(t *testing.T, bits int, c *BigComplex, expected uint64)
 	}
 }
 
-func newBigInt(i string) *BigComplex {
-	integer := new(BigComplex)
-	integer.Re.Denom().SetInt64(1)
-	if _, ok := integer.Re.Num().SetString(i, 0); !ok {
+func newBigInt(i string) *ConstNumber {
+	integer, ok := new(big.Int).SetString(i, 0)
+	if !ok {
 		panic("Invalid BigInt string '" + i + "'")
-	} else {
-		return integer
 	}
+	return &ConstNumber{constant.Make(integer), ConstInt}
 }
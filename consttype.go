@@ -94,17 +94,17 @@ func promoteConsts(ctx *Ctx, x, y ConstType, xexpr, yexpr Expr, xval, yval refle
 		case ConstIntType, ConstRuneType, ConstFloatType, ConstComplexType:
 			return promoteConstNumbers(x, y), nil
 		}
-		return nil, []error{ErrBadConstConversion{at(ctx, yexpr), y, x, yval}}
+		return nil, []error{ErrBadConstConversion{at(ctx, yexpr), y, x, yval, ""}}
 	case ConstStringType:
 		switch y.(type) {
 		case ConstStringType:
 			return x, nil
 		case ConstIntType, ConstRuneType, ConstFloatType, ConstComplexType:
-			return nil, []error{ErrBadConstConversion{at(ctx, xexpr), x, y, xval}}
+			return nil, []error{ErrBadConstConversion{at(ctx, xexpr), x, y, xval, ""}}
 		default:
 			return nil, []error{
-				ErrBadConstConversion{at(ctx, xexpr), x, ConstInt, xval},
-				ErrBadConstConversion{at(ctx, yexpr), y, ConstInt, yval},
+				ErrBadConstConversion{at(ctx, xexpr), x, ConstInt, xval, ""},
+				ErrBadConstConversion{at(ctx, yexpr), y, ConstInt, yval, ""},
 			}
 		}
 	case ConstNilType:
@@ -112,11 +112,11 @@ func promoteConsts(ctx *Ctx, x, y ConstType, xexpr, yexpr Expr, xval, yval refle
 		case ConstNilType:
 			return x, nil
 		case ConstIntType, ConstRuneType, ConstFloatType, ConstComplexType:
-			return nil, []error{ErrBadConstConversion{at(ctx, xexpr), x, y, xval}}
+			return nil, []error{ErrBadConstConversion{at(ctx, xexpr), x, y, xval, ""}}
 		default:
 			return nil, []error{
-				ErrBadConstConversion{at(ctx, xexpr), x, ConstInt, xval},
-				ErrBadConstConversion{at(ctx, yexpr), y, ConstInt, yval},
+				ErrBadConstConversion{at(ctx, xexpr), x, ConstInt, xval, ""},
+				ErrBadConstConversion{at(ctx, yexpr), y, ConstInt, yval, ""},
 			}
 		}
 	case ConstBoolType:
@@ -124,7 +124,7 @@ func promoteConsts(ctx *Ctx, x, y ConstType, xexpr, yexpr Expr, xval, yval refle
 		case ConstBoolType:
 			return x, nil
 		case ConstIntType, ConstRuneType, ConstFloatType, ConstComplexType, ConstStringType, ConstNilType:
-			return nil, []error{ErrBadConstConversion{at(ctx, yexpr), y, x, yval}}
+			return nil, []error{ErrBadConstConversion{at(ctx, yexpr), y, x, yval, ""}}
 		}
 	}
 	panic("go-interactive: impossible")
@@ -189,7 +189,7 @@ func promoteConstToTyped(ctx *Ctx, from ConstType, c constValue, to reflect.Type
 //
 func convertConstToTyped(ctx *Ctx, from ConstType, c constValue, to reflect.Type, isTypeCast bool, expr Expr) (
 	constValue, []error) {
-	v := hackedNew(to).Elem()
+	v := reflect.New(to).Elem()
 
 	switch from.(type) {
 	case ConstIntType, ConstRuneType, ConstFloatType, ConstComplexType:
@@ -197,15 +197,15 @@ func convertConstToTyped(ctx *Ctx, from ConstType, c constValue, to reflect.Type
 		switch to.Kind() {
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			var errs []error
-			i, truncation, overflow := underlying.Value.Int(to.Bits())
+			i, truncation, overflow := underlying.Int(to.Bits())
 			if truncation {
 				errs = append(errs, ErrTruncatedConstant{at(ctx, expr), ConstInt, underlying})
 			}
 			if overflow {
-				errs = append(errs, ErrOverflowedConstant{at(ctx, expr), from, to, underlying})
+				errs = append(errs, ErrOverflowedConstant{at(ctx, expr), from, to, underlying, ""})
 			}
 			// For some reason, the errors produced are "complex -> int" then "complex -> real"
-			_, truncation = underlying.Value.Real()
+			truncation = !underlying.IsReal()
 			if truncation {
 				errs = append(errs, ErrTruncatedConstant{at(ctx, expr), ConstFloat, underlying})
 			}
@@ -214,15 +214,15 @@ func convertConstToTyped(ctx *Ctx, from ConstType, c constValue, to reflect.Type
 
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 			var errs []error
-			u, truncation, overflow := underlying.Value.Uint(to.Bits())
+			u, truncation, overflow := underlying.Uint(to.Bits())
 			if truncation {
 				errs = append(errs, ErrTruncatedConstant{at(ctx, expr), ConstInt, underlying})
 			}
 			if overflow {
-				errs = append(errs, ErrOverflowedConstant{at(ctx, expr), from, to, underlying})
+				errs = append(errs, ErrOverflowedConstant{at(ctx, expr), from, to, underlying, ""})
 			}
 			// For some reason, the erros produced are "complex -> int" then "complex -> real"
-			_, truncation = underlying.Value.Real()
+			truncation = !underlying.IsReal()
 			if truncation {
 				errs = append(errs, ErrTruncatedConstant{at(ctx, expr), ConstFloat, underlying})
 			}
@@ -231,25 +231,32 @@ func convertConstToTyped(ctx *Ctx, from ConstType, c constValue, to reflect.Type
 
 		case reflect.Float32, reflect.Float64:
 			var errs []error
-			f, truncation, _ := underlying.Value.Float64()
+			f, truncation, overflow := underlying.Float(to.Bits())
 			if truncation {
-				errs = []error{ErrTruncatedConstant{at(ctx, expr), ConstFloat, underlying}}
+				errs = append(errs, ErrTruncatedConstant{at(ctx, expr), ConstFloat, underlying})
+			}
+			if overflow {
+				errs = append(errs, ErrOverflowedConstant{at(ctx, expr), from, to, underlying, ""})
 			}
 			v.SetFloat(f)
 			return constValue(v), errs
 
 		case reflect.Complex64, reflect.Complex128:
-			cmplx, _ := underlying.Value.Complex128()
+			var errs []error
+			cmplx, overflow := underlying.Complex(to.Bits())
+			if overflow {
+				errs = append(errs, ErrOverflowedConstant{at(ctx, expr), from, to, underlying, ""})
+			}
 			v.SetComplex(cmplx)
-			return constValue(v), nil
+			return constValue(v), errs
 
 		// string(97) is legal, equivalent of string('a'), but this
                 // conversion is not automatic. "abc" + 10 is illegal.
 		case reflect.String:
 			if isTypeCast && from.IsIntegral() {
-				i, _, overflow := underlying.Value.Int(32)
+				i, _, overflow := underlying.Int(32)
 				if overflow {
-					err := ErrOverflowedConstant{at(ctx, expr), from, ConstString, underlying}
+					err := ErrOverflowedConstant{at(ctx, expr), from, ConstString, underlying, ""}
 					return constValue{}, []error{err}
 				}
 				v.SetString(string(i))
@@ -295,7 +302,7 @@ func convertConstToTyped(ctx *Ctx, from ConstType, c constValue, to reflect.Type
 		}
 	}
 
-	return constValue{}, []error{ErrBadConstConversion{at(ctx, expr), from, to, reflect.Value(c)}}
+	return constValue{}, []error{ErrBadConstConversion{at(ctx, expr), from, to, reflect.Value(c), ""}}
 }
 
 // Convert a typed numeric value to a const number. Ok is false if v is not numeric
@@ -1,5 +1,79 @@
 package eval
 
+import (
+	"context"
+	"io"
+)
+
 type Ctx struct {
 	Input string
+
+	// If true, expressions which may have side effects are rejected by
+	// CheckExpr with an ErrSideEffect. This is meant for watch
+	// expressions and breakpoint conditions, which must never change
+	// the program being debugged. Rejected are function and method
+	// calls not listed in PureFuncs, channel receives, the builtins
+	// append, copy, delete, clear, close, print, println and recover,
+	// and taking the address of anything but a composite literal. The
+	// address of a variable is rejected wherever it is taken, even in a
+	// harmless comparison such as &x == p, as the pointer could otherwise
+	// escape as a call argument or the result, and be written through.
+	ReadOnly bool
+
+	// Functions which may be called when ReadOnly is set. Keys are
+	// the function as it would be written in the expression, such as
+	// "f" or "strings.HasPrefix". Methods are keyed by the receiver
+	// type they are declared with and their name, such as
+	// "time.Time.String" or "*bytes.Buffer.Len", whether called through
+	// a value, a pointer or a method expression.
+	PureFuncs map[string]bool
+
+	// If non-nil, consulted for every function or method which the
+	// expression calls or selects.
+	Policy Policy
+
+	// If true, unexported struct fields of packages other than that of the
+	// Env may be selected, which CheckExpr otherwise rejects as Go does.
+	// Values read from them are re-wrapped so that they may be used in
+	// operators, comparisons and calls, and shown by Inspect, just like
+	// exported ones. This is intended for debuggers. Unexported methods
+	// remain inaccessible, as reflect provides no way of calling them.
+	Unexported bool
+
+	// If true along with Unexported, the re-wrapped values alias the
+	// fields themselves, and so setting them modifies the original struct.
+	// Otherwise they are copies.
+	UnexportedWritable bool
+
+	// Destination of the print and println builtins. As in Go, this
+	// is os.Stderr if nil.
+	Stderr io.Writer
+
+	// Maximum number of nested calls of functions declared by EvalDecls,
+	// 10000 if zero. Deeper calls fail with a PanicStackOverflow rather
+	// than exhausting the goroutine stack. The limit applies to each chain
+	// of interpreted calls, which continues through host code calling back
+	// into the interpreter. Go statements, and calls from host code outside
+	// of any evaluation, begin a new chain.
+	MaxCallDepth int
+
+	// If non-nil, channel operations in functions declared by EvalDecls
+	// which are blocked when it is done fail with a PanicCanceled, so that
+	// an interpreted select or receive cannot hang its caller forever. A
+	// receive outside of any function, such as Eval("<-ch"), then blocks
+	// too; see DetectDeadlock.
+	Context context.Context
+
+	// If true, a blocked channel operation in a function declared by
+	// EvalDecls fails with a PanicDeadlock once every interpreted goroutine
+	// has been blocked for a while. Host goroutines and timers which could
+	// unblock them are not seen, so this is only sound for code which
+	// communicates with itself. Otherwise blocked operations wait as in
+	// Go, and Context bounds how long.
+	//
+	// A receive outside of any function, such as Eval("<-ch"), blocks
+	// only if DetectDeadlock is set or Context is non-nil. Otherwise it
+	// fails with a PanicWouldBlock when nothing can be received, rather
+	// than hanging the caller.
+	DetectDeadlock bool
 }
@@ -0,0 +1,434 @@
+package eval
+
+import (
+	"errors"
+	"reflect"
+
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+)
+
+// Declarations are parsed as the body of a file with this header. It
+// shares the first line with the declarations, so only columns on the
+// first line need correcting.
+const declPrefix = "package main;"
+
+// EvalDecls parses src as a sequence of const, var, type and func
+// declarations, separated by semicolons or newlines, and binds them in env,
+// replacing any existing binding of the same name. Declarations are made in
+// order, so each may refer to those before it. Funcs are bound first, so
+// that their bodies may also call funcs declared after them, and calling a
+// func from an initializer before its declaration fails with a
+// PanicFuncNotDeclared. If a declaration fails to check, its error is
+// returned and the declarations before it remain bound.
+//
+// Consts may use iota and implicit repetition, and are folded as in
+// Go. Untyped numeric constants remain untyped. Vars are new zero or
+// initialized values, and so are addressable.
+//
+// reflect cannot create new named types, so a declared type is bound to
+// its underlying type, in the manner of an alias. Type declarations may
+// therefore not be recursive, and methods cannot be declared on them.
+//
+// Funcs are bound in env.Funcs as reflect.MakeFunc values, so host code
+// may call them like any other func. Their bodies are checked when
+// declared and interpreted on each call. A call runs under the options of
+// the Ctx of the evaluation making it, which host code called by that
+// evaluation passes on, or of the Ctx the func was declared with when host
+// code calls it outside of any evaluation. A func may call itself, and
+// nested calls are limited to MaxCallDepth. Runtime panics in a func run
+// its deferred calls and may be recovered by them. Otherwise they are
+// returned by the Eval that called it, and panic host code which calls it
+// directly. A go statement runs its call on a new goroutine, printing any
+// panic to Stderr. Channel operations in a func block as in Go, until
+// Context is done. With DetectDeadlock, they also fail with a
+// PanicDeadlock once every interpreted goroutine is blocked. Goroutines
+// reports those which remain. Func literals, here and in expressions, are
+// closures over the variables of their enclosing scopes.
+// Methods and goto are not supported.
+func EvalDecls(src string, env *Env) error {
+	return EvalDeclsCtx(&Ctx{Input: src}, env)
+}
+
+// EvalDeclsCtx is EvalDecls for the declarations in ctx.Input, checked and
+// evaluated under the options set in ctx.
+func EvalDeclsCtx(ctx *Ctx, env *Env) error {
+	declCtx := *ctx
+	declCtx.Input = declPrefix + ctx.Input
+	file, err := parseDecls(&declCtx)
+	if err != nil {
+		return err
+	}
+	funcs := declareFuncs(&declCtx, file.Decls, env)
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			err = evalGenDecl(&declCtx, decl, env)
+		case *ast.FuncDecl:
+			err = evalFuncDecl(&declCtx, decl, env, funcs[decl])
+			delete(funcs, decl)
+		}
+		if err != nil {
+			// Funcs after the failed declaration are not declared
+			for _, pending := range funcs {
+				pending.restore()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func parseDecls(ctx *Ctx) (*ast.File, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", ctx.Input, 0)
+	if list, ok := err.(scanner.ErrorList); ok {
+		for _, e := range list {
+			e.Pos.Offset -= len(declPrefix)
+			if e.Pos.Line == 1 {
+				e.Pos.Column -= len(declPrefix)
+			}
+		}
+	}
+	return file, err
+}
+
+func evalGenDecl(ctx *Ctx, decl *ast.GenDecl, env *Env) error {
+	switch decl.Tok {
+	case token.IMPORT:
+		return errors.New("import declarations not implemented, add packages to Env.Pkgs")
+	case token.CONST:
+		return evalConstDecl(ctx, decl, env)
+	}
+	for _, spec := range decl.Specs {
+		var err error
+		switch spec := spec.(type) {
+		case *ast.ValueSpec:
+			err = evalVarSpec(ctx, spec, env)
+		case *ast.TypeSpec:
+			err = evalTypeSpec(ctx, spec, env)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Declare the consts of decl
+func evalConstDecl(ctx *Ctx, decl *ast.GenDecl, env *Env) error {
+	var last *ast.ValueSpec
+	for iota, spec := range decl.Specs {
+		spec := spec.(*ast.ValueSpec)
+		typ, values := spec.Type, spec.Values
+		if values != nil {
+			last = spec
+		} else if typ != nil {
+			return CheckErrors{ErrConstTypeWithoutExpr{at(ctx, spec)}}
+		} else if last == nil {
+			return CheckErrors{ErrMissingInitExpr{at(ctx, spec)}}
+		} else {
+			// Repeat the last type and values. Checking annotates the
+			// ast in place, so a fresh copy is needed for each repetition.
+			typ, values = nil, make([]ast.Expr, len(last.Values))
+			if last.Type != nil {
+				typ = reparseExpr(ctx, last.Type)
+			}
+			for i, value := range last.Values {
+				values[i] = reparseExpr(ctx, value)
+			}
+		}
+
+		scope := NewScope(env)
+		scope.Consts["iota"] = reflect.ValueOf(NewConstInt64(int64(iota)))
+		consts, errs := checkConstSpec(ctx, spec, typ, values, scope)
+		if errs != nil {
+			return CheckErrors(errs)
+		}
+		for i, name := range spec.Names {
+			if name.Name != "_" {
+				env.bindValue(env.Consts, name.Name, consts[i])
+			}
+		}
+	}
+	return nil
+}
+
+func checkConstSpec(ctx *Ctx, spec *ast.ValueSpec, typ ast.Expr, values []ast.Expr, env *Env) ([]reflect.Value, []error) {
+	if len(values) < len(spec.Names) {
+		return nil, []error{ErrMissingInitExpr{at(ctx, spec)}}
+	} else if len(values) > len(spec.Names) {
+		return nil, []error{ErrExtraInitExpr{at(ctx, spec)}}
+	}
+
+	var t reflect.Type
+	if typ != nil {
+		var errs []error
+		if _, t, _, errs = checkType(ctx, typ, env); errs != nil {
+			return nil, errs
+		}
+		switch t.Kind() {
+		case reflect.Bool, reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		default:
+			return nil, []error{ErrInvalidConstType{at(ctx, typ), t}}
+		}
+	}
+
+	var errs []error
+	consts := make([]reflect.Value, len(values))
+	for i, value := range values {
+		aexpr, moreErrs := CheckExpr(ctx, value, env)
+		if moreErrs != nil {
+			errs = append(errs, moreErrs...)
+			continue
+		}
+		from, err := expectSingleType(ctx, aexpr.KnownType(), aexpr)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		} else if !aexpr.IsConst() || from == ConstNil {
+			errs = append(errs, ErrConstInitNotConst{at(ctx, aexpr)})
+			continue
+		}
+
+		c := aexpr.Const()
+		if t == nil {
+			consts[i] = c
+		} else if ct, ok := from.(ConstType); ok {
+			v, moreErrs := promoteConstToTyped(ctx, ct, constValue(c), t, aexpr)
+			if moreErrs != nil {
+				errs = append(errs, moreErrs...)
+			}
+			consts[i] = reflect.Value(v)
+		} else if typeAssignableTo(from, t) {
+			consts[i] = c
+		} else {
+			errs = append(errs, ErrBadAssignType{at(ctx, aexpr), from, t})
+		}
+	}
+	return consts, errs
+}
+
+func evalVarSpec(ctx *Ctx, spec *ast.ValueSpec, env *Env) error {
+	vars, errs := checkVarSpec(ctx, spec, env)
+	if errs != nil {
+		return CheckErrors(errs)
+	}
+	return declareVars(ctx, spec, vars, env)
+}
+
+// Evaluate the initializers of spec, as checked by checkVarSpec, and
+// bind the new variables in env.
+func declareVars(ctx *Ctx, spec *ast.ValueSpec, vars []checkedVar, env *Env) error {
+	xs, err := evalAssignExprs(ctx, vars, env)
+	if err != nil {
+		return err
+	}
+	for i, name := range spec.Names {
+		ptr := reflect.New(vars[i].t)
+		if xs[i].IsValid() {
+			ptr.Elem().Set(xs[i])
+		}
+		if name.Name != "_" {
+			env.bindValue(env.Vars, name.Name, ptr)
+		}
+	}
+	return nil
+}
+
+// A checked variable or assignment: its type and, if initialized, its
+// initializer. For a multi-valued initializer, only the first var holds the
+// expr, and commaOk is set if it is a map index, type assertion or receive
+// whose second value is the ok bool.
+type checkedVar struct {
+	t       reflect.Type
+	expr    Expr
+	commaOk bool
+}
+
+func checkVarSpec(ctx *Ctx, spec *ast.ValueSpec, env *Env) ([]checkedVar, []error) {
+	var t reflect.Type
+	if spec.Type != nil {
+		var errs []error
+		if _, t, _, errs = checkType(ctx, spec.Type, env); errs != nil {
+			return nil, errs
+		}
+	}
+
+	types := make([]reflect.Type, len(spec.Names))
+	for i := range types {
+		types[i] = t
+	}
+	if len(spec.Values) == 0 {
+		vars := make([]checkedVar, len(types))
+		for i := range vars {
+			vars[i].t = t
+		}
+		return vars, nil
+	}
+	return checkAssignExprs(ctx, spec, types, spec.Values, true, env)
+}
+
+// Check values assigned to len(types) variables. Where types[i] is nil, the
+// variable takes the type of its value, as with var x = v or x := v. node is
+// the declaration or statement, for reporting count mismatches. If commaOk,
+// a single map index, type assertion or receive may be assigned to two
+// variables.
+func checkAssignExprs(ctx *Ctx, node ast.Node, types []reflect.Type, values []ast.Expr, commaOk bool, env *Env) ([]checkedVar, []error) {
+	vars := make([]checkedVar, len(types))
+	var errs []error
+	if len(values) == 1 && len(types) > 1 {
+		aexpr, moreErrs := CheckExpr(ctx, values[0], env)
+		if moreErrs != nil {
+			return nil, moreErrs
+		}
+		from := aexpr.KnownType()
+		if commaOk && len(types) == 2 && len(from) == 1 && isCommaOkExpr(aexpr) {
+			vars[0].commaOk = true
+			from = []reflect.Type{from[0], boolType}
+		} else if len(from) != len(types) {
+			return nil, []error{ErrAssignCountMismatch{at(ctx, node), len(types), len(from)}}
+		}
+		vars[0].expr = aexpr
+		for i, t := range types {
+			if t == nil {
+				vars[i].t = from[i]
+			} else if typeAssignableTo(from[i], t) || (i == 1 && vars[0].commaOk && t.Kind() == reflect.Bool) {
+				// The ok of a comma ok expression is an untyped bool
+				vars[i].t = t
+			} else {
+				errs = append(errs, ErrBadAssignType{at(ctx, aexpr), from[i], t})
+			}
+		}
+		return vars, errs
+	} else if len(values) != len(types) {
+		return nil, []error{ErrAssignCountMismatch{at(ctx, node), len(types), len(values)}}
+	}
+
+	for i, value := range values {
+		if t := types[i]; t != nil {
+			aexpr, ok, moreErrs := checkExprAssignableTo(ctx, value, t, env)
+			vars[i] = checkedVar{t: t, expr: aexpr}
+			if moreErrs != nil {
+				errs = append(errs, moreErrs...)
+			} else if !ok {
+				errs = append(errs, ErrBadAssignType{at(ctx, aexpr), aexpr.KnownType()[0], t})
+			}
+			continue
+		}
+
+		aexpr, moreErrs := CheckExpr(ctx, value, env)
+		if moreErrs != nil {
+			errs = append(errs, moreErrs...)
+			continue
+		}
+		from, err := expectSingleType(ctx, aexpr.KnownType(), aexpr)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ct, ok := from.(ConstType); ok {
+			if ct == ConstNil {
+				errs = append(errs, ErrUntypedNil{at(ctx, aexpr)})
+				continue
+			}
+			from = ct.DefaultPromotion()
+			// Report overflows, such as var x = 1 << 100
+			if _, moreErrs := exprAssignableTo(ctx, aexpr, from); moreErrs != nil {
+				errs = append(errs, moreErrs...)
+			}
+		}
+		vars[i] = checkedVar{t: from, expr: aexpr}
+	}
+	return vars, errs
+}
+
+// Evaluate the values checked by checkAssignExprs, each converted to the
+// type of its variable. Uninitialized vars have invalid values.
+func evalAssignExprs(ctx *Ctx, vars []checkedVar, env *Env) ([]reflect.Value, error) {
+	xs := make([]reflect.Value, len(vars))
+	if len(vars) == 0 || vars[0].expr == nil {
+		return xs, nil
+	} else if len(vars) > 1 && vars[1].expr == nil {
+		if vars[0].commaOk {
+			x, ok, err := evalCommaOk(ctx, vars[0].expr, env)
+			if err != nil {
+				return nil, err
+			}
+			xs[0], xs[1] = x, reflect.ValueOf(ok).Convert(vars[1].t)
+			return xs, nil
+		}
+		results, _, err := EvalExpr(ctx, vars[0].expr, env)
+		if err != nil {
+			return nil, err
+		}
+		copy(xs, *results)
+		return xs, nil
+	}
+	for i, v := range vars {
+		x, err := evalTypedExpr(ctx, v.expr, knownType{v.t}, env)
+		if err != nil {
+			return nil, err
+		}
+		xs[i] = x[0]
+	}
+	return xs, nil
+}
+
+func evalTypeSpec(ctx *Ctx, spec *ast.TypeSpec, env *Env) error {
+	if spec.TypeParams != nil {
+		return errors.New("generic type declarations not implemented")
+	}
+	_, t, _, errs := checkType(ctx, spec.Type, env)
+	if errs != nil {
+		return CheckErrors(errs)
+	}
+	if spec.Name.Name != "_" {
+		envMu.Lock()
+		env.unbind(spec.Name.Name)
+		env.Types[spec.Name.Name] = t
+		envMu.Unlock()
+	}
+	return nil
+}
+
+// Parse expr again, producing fresh nodes at the same positions in
+// ctx.Input. Checking annotates the ast in place, so this is needed to
+// check an expression more than once.
+func reparseExpr(ctx *Ctx, expr ast.Expr) ast.Expr {
+	fset := token.NewFileSet()
+	if expr.Pos() > 1 {
+		// Offset the file so that its base is expr.Pos()
+		fset.AddFile("", -1, int(expr.Pos())-2)
+	}
+	src := ctx.Input[expr.Pos()-1:expr.End()-1]
+	fresh, err := parser.ParseExprFrom(fset, "", src, 0)
+	if err != nil {
+		panic(dytc("reparse of " + src + " failed: " + err.Error()))
+	}
+	return fresh
+}
+
+// Bind name to v in bindings, one of the value maps of env, replacing any
+// existing binding of name.
+func (env *Env) bindValue(bindings map[string]reflect.Value, name string, v reflect.Value) {
+	envMu.Lock()
+	defer envMu.Unlock()
+	env.unbind(name)
+	bindings[name] = v
+}
+
+// Remove any binding of name from env, so that it may be redeclared
+func (env *Env) unbind(name string) {
+	if f, ok := env.Funcs[name]; ok {
+		forgetInterpFunc(f)
+	}
+	delete(env.Vars, name)
+	delete(env.Consts, name)
+	delete(env.Funcs, name)
+	delete(env.Types, name)
+}
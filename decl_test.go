@@ -0,0 +1,148 @@
+package eval
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvalDeclsConst(t *testing.T) {
+	env := NewEnv()
+	err := EvalDecls(`const Max = 10
+const (
+	A = iota * Max
+	B
+	C
+	_
+	E int8 = iota + 'a'
+	F
+)
+const Big = 1000000000000000000 * 1000000000000000000; const S, T = "s", Max > 5`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectConst(t, "Max", env, NewConstInt64(10), ConstInt)
+	expectConst(t, "C", env, NewConstInt64(20), ConstInt)
+	expectConst(t, "E", env, int8(101), reflect.TypeOf(int8(0)))
+	expectConst(t, "F", env, int8(102), reflect.TypeOf(int8(0)))
+	expectConst(t, "Big / Max / 1000000000000000000", env, NewConstInt64(100000000000000000), ConstInt)
+	expectResult(t, "S", env, "s")
+	expectResult(t, "T", env, true)
+}
+
+func TestEvalDeclsVar(t *testing.T) {
+	env := NewEnv()
+	env.SetFunc("divmod", func(a, b int) (int, int) { return a / b, a % b })
+	err := EvalDecls(`var buf []byte; var x, y = 1.5, "y"
+var q, r = divmod(7, 2)
+var i interface{} = x
+var arr [3]int
+var p = &arr`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, "buf == nil", env, true)
+	expectResult(t, "x", env, 1.5)
+	expectResult(t, "y", env, "y")
+	expectResult(t, "q*2 + r", env, 7)
+	expectResult(t, "i", env, interface{}(1.5))
+	expectResult(t, "len(p[:2])", env, 2)
+	expectResult(t, "p[1]", env, 0)
+	expectResult(t, "arr[1:]", env, []int{0, 0})
+	expectResult(t, "[2]int{1, 2}[1]", env, 2)
+	expectResult(t, "&x != nil", env, true)
+}
+
+func TestEvalDeclsType(t *testing.T) {
+	env := NewEnv()
+	err := EvalDecls(`type Pair struct{A, B int; s string "tag"}
+type Pairs []Pair
+type F func(int, ...string) (bool, error)
+type A [1 << 20]int
+var ps = Pairs{{1, 2, "x"}, {A: 3}}`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, "ps[1].A + ps[0].B", env, 5)
+
+	pairT := env.Types["Pair"]
+	if pairT.Kind() != reflect.Struct || pairT.NumField() != 3 || pairT.Field(2).Tag != "tag" {
+		t.Fatalf("Pair declared as %v", pairT)
+	}
+	if f := env.Types["F"]; f.String() != "func(int, ...string) (bool, error)" {
+		t.Fatalf("F declared as %v", f)
+	}
+	if a := env.Types["A"]; a.Len() != 1<<20 {
+		t.Fatalf("A declared as %v", a)
+	}
+}
+
+func TestEvalDeclsUnexportedFields(t *testing.T) {
+	env := NewEnv()
+	err := EvalDecls(`type P struct{ x int; in struct{ y string } }
+var p = P{1, struct{ y string }{"a"}}
+var xx = p.x
+func get(q P) int {
+	a := q.x
+	return a
+}
+func set(q *P, x int) string {
+	q.x = x
+	q.in.y += "b"
+	return q.in.y
+}`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, "xx", env, 1)
+	expectResult(t, "p.x + 1", env, 2)
+	expectResult(t, "get(p)", env, 1)
+	expectResult(t, "set(&p, 3)", env, "ab")
+	expectResult(t, "p.x", env, 3)
+	if s, err := EvalString("p.x", env); err != nil || s != "3" {
+		t.Fatalf("Inspect(p.x) = %s, %v", s, err)
+	}
+}
+
+func TestEvalDeclsRedeclare(t *testing.T) {
+	env := NewEnv()
+	if err := EvalDecls("var x = 1", env); err != nil {
+		t.Fatal(err)
+	}
+	if err := EvalDecls("const x = 2", env); err != nil {
+		t.Fatal(err)
+	}
+	expectConst(t, "x", env, NewConstInt64(2), ConstInt)
+}
+
+func expectDeclError(t *testing.T, src string, env *Env, errorString string) {
+	if err := EvalDecls(src, env); err == nil {
+		t.Fatalf("Expected error for '%s'", src)
+	} else if err.Error() != errorString {
+		t.Fatalf("Error `%v` != Expected `%v` for '%s'", err, errorString, src)
+	}
+}
+
+func TestEvalDeclsErrors(t *testing.T) {
+	env := NewEnv()
+	x := 1
+	env.SetVar("x", &x)
+	expectDeclError(t, "const c = x", env, "const initializer x is not a constant")
+	expectDeclError(t, "const c int8 = 300", env, "constant 300 overflows int8")
+	expectDeclError(t, "const c []int = nil", env, "invalid constant type []int")
+	expectDeclError(t, "const (a = 1; b int)", env, "const declaration cannot have type without expression")
+	expectDeclError(t, "const a, b = 1", env, "missing init expr for const declaration")
+	expectDeclError(t, "var a, b = 1, 2, 3", env, "assignment count mismatch: 2 = 3")
+	expectDeclError(t, `var s string = x`, env, "cannot use x (type int) as type string in assignment")
+	expectDeclError(t, "var n = nil", env, "use of untyped nil")
+	expectDeclError(t, "type T struct{a, a int}", env, "duplicate field a")
+	expectDeclError(t, "type T [-1]int", env, "invalid array bound -1")
+	expectDeclError(t, "var q = 1 << 100", env, "constant 1267650600228229401496703205376 overflows int")
+	expectDeclError(t, "type T func() ...int", env, "1:15: expected ';', found '...'")
+	expectDeclError(t, "var y = undefined", env, "undefined: undefined")
+	expectDeclError(t, "var y = ", env, "1:9: expected operand, found 'EOF'")
+	expectDeclError(t, `import "fmt"`, env, "import declarations not implemented, add packages to Env.Pkgs")
+
+	// Earlier declarations remain
+	expectDeclError(t, "var ok = 1; var bad = undefined", env, "undefined: undefined")
+	expectResult(t, "ok", env, 1)
+}
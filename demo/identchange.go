@@ -33,7 +33,7 @@ func MyEvalIdentExpr(ctx *eval.Ctx, ident *eval.Ident, env *eval.Env) (
 
 
 func expectResult(expr string, env *eval.Env, expected interface{}) {
-	ctx := &eval.Ctx{expr}
+	ctx := &eval.Ctx{Input: expr}
 	if e, err := parser.ParseExpr(expr); err != nil {
 		fmt.Printf("Failed to parse expression '%s' (%v)\n", expr, err)
 		return
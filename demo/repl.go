@@ -13,72 +13,264 @@
 // the ssa-debugger tortoise/gub.sh. Right now that can't handle the
 // unsafe package, pointers, and calls to C code. So that let's out
 // go-gnureadline and lineedit.
+//
+// The -lineedit flag instead turns on the pure Go line editor from
+// github.com/0xfaded/eval/repl, which gives history, Ctrl-R search and
+// tab completion without any of the above. History is kept in the
+// file named by -history.
+//
+// Lines starting with const, var or type are declarations, which are
+// added to the environment by eval.EvalDecls.
 package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"go/ast"
 	"go/parser"
+	"go/scanner"
+	"go/token"
 	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
 	"strings"
 
 	"github.com/0xfaded/eval"
+	"github.com/0xfaded/eval/repl"
 )
 
+// LineReader reads a line of input after showing a prompt. It is
+// satisfied by *repl.LineEditor.
+type LineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
 // Simple replacement for GNU readline
-func readline(prompt string, in *bufio.Reader) (string, error) {
-	fmt.Printf(prompt)
-	line, err := in.ReadString('\n')
+type simpleReader struct {
+	in *bufio.Reader
+}
+
+func (r simpleReader) ReadLine(prompt string) (string, error) {
+	fmt.Print(prompt)
+	line, err := r.in.ReadString('\n')
 	if err == nil {
 		line = strings.TrimRight(line, "\r\n")
 	}
 	return line, err
 }
 
+// readExpr reads an expression which may span several lines. While
+// the input so far is incomplete, a continuation prompt is shown and
+// further lines are appended. An empty continuation line stops the
+// reading so that the parse error gets reported instead.
+func readExpr(in LineReader) (string, error) {
+	src, err := in.ReadLine("go> ")
+	if err != nil || src == "quit" {
+		return src, err
+	}
+	for {
+		if _, perr := parser.ParseExpr(src); perr == nil || !incomplete(src, perr) {
+			return src, nil
+		}
+		line, err := in.ReadLine("..> ")
+		if err != nil {
+			return src, err
+		}
+		if line == "" {
+			return src, nil
+		}
+		src += "\n" + line
+	}
+}
+
+// incomplete reports whether src failed to parse, with parse error
+// perr, only because more input is needed. That is the case when
+// brackets are left open, a raw string is unterminated, or the
+// parser expected something more at EOF.
+func incomplete(src string, perr error) bool {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, []byte(src), nil, 0)
+	depth := 0
+	for _, tok, _ := s.Scan(); tok != token.EOF; _, tok, _ = s.Scan() {
+		switch tok {
+		case token.LPAREN, token.LBRACK, token.LBRACE:
+			depth += 1
+		case token.RPAREN, token.RBRACK, token.RBRACE:
+			depth -= 1
+		}
+	}
+	if depth > 0 {
+		return true
+	}
+
+	list, ok := perr.(scanner.ErrorList)
+	if !ok {
+		return false
+	}
+	for _, err := range list {
+		if strings.HasSuffix(err.Msg, "found 'EOF'") ||
+			err.Msg == "raw string literal not terminated" {
+			return true
+		}
+	}
+	return false
+}
+
 func intro_text() {
 	fmt.Printf(`=== A simple Go eval REPL ===
 
-Results of expression are stored in variable slice "results".
-The environment is stored in global variable "env".
-
-Enter expressions to be evaluated at the "go>" prompt.
+The result of each expression is stored in a numbered variable, _1,
+_2 and so on, which keeps the static type of the result. The variable
+_ always holds the most recent result. Multiple results are stored as
+an array, or as a []interface{} when their types differ, so the first
+of them is _3[0].
 
-To see all results, type: "results".
+Enter expressions to be evaluated at the "go>" prompt. An expression
+can span several lines; while it is incomplete, the prompt changes to
+"..>". Enter an empty line there to give up on it.
 
 To quit, enter: "quit" or Ctrl-D (EOF).
 `)
 
 }
 
-// REPL is the a read, eval, and print loop.
-func REPL(env *eval.Env) {
+// printErrorPos shows the line of source that errmsg, which starts
+// with a "line:column: " position, refers to along with a caret under
+// the offending column.
+func printErrorPos(source, errmsg string) {
+	if pair := eval.FormatErrorPos(source, errmsg); len(pair) == 2 {
+		fmt.Println(pair[0])
+		fmt.Println(pair[1])
+	}
+}
+
+// isDecl reports whether line holds declarations rather than an expression.
+func isDecl(line string) bool {
+	if fields := strings.Fields(line); len(fields) > 0 {
+		switch fields[0] {
+		case "const", "var", "type":
+			return true
+		case "func":
+			// A named func, rather than a func literal
+			return len(fields) > 1 && !strings.HasPrefix(fields[1], "(")
+		}
+	}
+	return false
+}
+
+// posPrefix turns pos, as recorded by parser.ParseExpr(source), into
+// the "line:column: " form used by parse errors.
+func posPrefix(source string, pos token.Pos) string {
+	offset := int(pos) - 1
+	if offset < 0 || offset > len(source) {
+		return ""
+	}
+	before := source[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := offset - strings.LastIndex(before, "\n")
+	return fmt.Sprintf("%d:%d: ", line, column)
+}
+
+// bindResult stores the values of the n'th expression, cexpr, in the
+// variable _n, and also in _ until the next result comes along. The
+// variable has the type of the value, so that expressions like _1.Field
+// type check. Multiple values are stored as an array, or as a
+// []interface{} when their types differ. Constants are stored as
+// constants so that they stay untyped. The name of the variable is
+// returned. A value read through an unexported field cannot be stored
+// without the unsafe package, so no variable is bound for it and ok is
+// false.
+func bindResult(env *eval.Env, n int, cexpr eval.Expr, vals []reflect.Value) (name string, ok bool) {
+	name = fmt.Sprintf("_%d", n)
+	var v reflect.Value
+	if len(vals) == 1 && cexpr.IsConst() {
+		v = vals[0]
+	} else if len(vals) == 1 {
+		if !vals[0].CanInterface() {
+			return "", false
+		}
+		v = reflect.New(vals[0].Type())
+		v.Elem().Set(vals[0])
+	} else {
+		t := vals[0].Type()
+		for _, val := range vals {
+			if !val.CanInterface() {
+				return "", false
+			}
+			if val.Type() != t {
+				t = nil
+			}
+		}
+		if t != nil {
+			v = reflect.New(reflect.ArrayOf(len(vals), t))
+			for i, val := range vals {
+				v.Elem().Index(i).Set(val)
+			}
+		} else {
+			multi := make([]interface{}, len(vals))
+			for i, val := range vals {
+				multi[i] = val.Interface()
+			}
+			v = reflect.ValueOf(&multi)
+		}
+	}
 
-	var err error
+	for _, name := range []string{name, "_"} {
+		if cexpr.IsConst() {
+			delete(env.Vars, name)
+			env.Consts[name] = v
+		} else {
+			delete(env.Consts, name)
+			env.Vars[name] = v
+		}
+	}
+	return name, true
+}
 
-	// A place to store result values of expressions entered
-	// interactively
-	results := make([] interface{}, 0, 10)
-	env.Vars["results"] = reflect.ValueOf(&results)
+// notBound is printed in place of the variable name of a result which
+// bindResult could not store.
+const notBound = "(not bound: read through an unexported field)"
+
+// REPL is the a read, eval, and print loop.
+func REPL(env *eval.Env, in LineReader) {
 
 	exprs := 0
-	in := bufio.NewReader(os.Stdin)
-	line, err := readline("go> ", in)
+	line, err := readExpr(in)
 	for line != "quit" {
-		if err != nil {
+		if err == repl.ErrInterrupted {
+			line, err = readExpr(in)
+			continue
+		} else if err != nil {
 			if err == io.EOF { break }
 			panic(err)
 		}
-		ctx := &eval.Ctx{line}
-		if expr, err := parser.ParseExpr(line); err != nil {
-			if pair := eval.FormatErrorPos(line, err.Error()); len(pair) == 2 {
-				fmt.Println(pair[0])
-				fmt.Println(pair[1])
+		// No host channels or timers are bound here, so every channel
+		// is fed by interpreted code, and a blocked operation can safely
+		// fail as a deadlock
+		ctx := &eval.Ctx{Input: line, DetectDeadlock: true}
+		if isDecl(line) {
+			if err := eval.EvalDeclsCtx(ctx, env); err != nil {
+				if _, ok := err.(scanner.ErrorList); ok {
+					printErrorPos(line, err.Error())
+				}
+				fmt.Printf("%v\n", err)
 			}
+			line, err = readExpr(in)
+			continue
+		}
+		if expr, err := parser.ParseExpr(line); err != nil {
+			printErrorPos(line, err.Error())
 			fmt.Printf("parse error: %s\n", err)
 		} else if cexpr, errs := eval.CheckExpr(ctx, expr, env); len(errs) != 0 {
 			for _, cerr := range errs {
+				if node, ok := cerr.(ast.Node); ok {
+					printErrorPos(line, posPrefix(line, node.Pos()))
+				}
 				fmt.Printf("%v\n", cerr)
 			}
 		} else if vals, _, err := eval.EvalExpr(ctx, cexpr, env); err != nil {
@@ -98,25 +290,34 @@ func REPL(env *eval.Env) {
 				} else {
 					fmt.Printf("Kind = Type = %v\n", kind)
 				}
-				fmt.Printf("results[%d] = %s\n", exprs, eval.Inspect(value))
-				exprs += 1
-				results = append(results, (*vals)[0].Interface())
+				name, ok := bindResult(env, exprs+1, cexpr, *vals)
+				if ok {
+					exprs += 1
+				} else {
+					name = notBound
+				}
+				fmt.Printf("%s = %s\n", name, eval.Inspect(value))
 			} else {
 				fmt.Printf("%s\n", value)
 			}
 		} else {
 			fmt.Printf("Kind = Multi-Value\n")
+			name, ok := bindResult(env, exprs+1, cexpr, *vals)
+			if ok {
+				exprs += 1
+			} else {
+				name = notBound
+			}
+			fmt.Printf("%s = ", name)
 			size := len(*vals)
 			for i, v := range *vals {
 				fmt.Printf("%s", eval.Inspect(v))
 				if i < size-1 { fmt.Printf(", ") }
 			}
 			fmt.Printf("\n")
-			exprs += 1
-			results = append(results, (*vals))
 		}
 
-		line, err = readline("go> ", in)
+		line, err = readExpr(in)
 	}
 }
 
@@ -145,7 +346,7 @@ func (Z) x() {}
 //      type Alice
 //      var  alice, aliceptr
 //
-// (REPL also adds var results to main)
+// (REPL also adds the result variables _, _1, _2, ... to main)
 //
 // See make_env in github.com/rocky/go-fish for an automated way to
 // create more complete environment from a starting import.
@@ -245,8 +446,90 @@ func makeBogusEnv() eval.Env {
 	return mainEnv
 }
 
+// stty runs stty(1) on the terminal attached to standard input and
+// returns its output.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// loadSession restores variables and constants saved by an earlier
+// session. A missing file is not an error.
+func loadSession(path string, env *eval.Env) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot load session: %v\n", err)
+		return
+	}
+	defer f.Close()
+	skipped, err := eval.LoadEnv(f, env)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot load session: %v\n", err)
+	}
+	for _, err := range skipped {
+		fmt.Fprintf(os.Stderr, "not restored: %v\n", err)
+	}
+}
+
+// saveSession writes the variables and constants of env to path.
+func saveSession(path string, env *eval.Env) {
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot save session: %v\n", err)
+		return
+	}
+	defer f.Close()
+	skipped, err := eval.SaveEnv(f, env)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot save session: %v\n", err)
+	}
+	for _, err := range skipped {
+		fmt.Fprintf(os.Stderr, "not saved: %v\n", err)
+	}
+}
+
 func main() {
+	lineedit := flag.Bool("lineedit", false, "use the line editor; needs stty(1)")
+	history := flag.String("history", filepath.Join(os.Getenv("HOME"), ".go-repl_history"),
+		"file to keep line editor history in")
+	session := flag.String("session", "", "file to restore variables from and save them to on exit")
+	flag.Parse()
+
 	env := makeBogusEnv()
+	if *session != "" {
+		loadSession(*session, &env)
+		defer saveSession(*session, &env)
+	}
 	intro_text()
-	REPL(&env)
+
+	if !*lineedit {
+		REPL(&env, simpleReader{bufio.NewReader(os.Stdin)})
+		return
+	}
+
+	// The editor wants every key as it is typed, with no echo.
+	saved, err := stty("-g")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot query terminal: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := stty("-icanon", "-echo", "-isig", "min", "1"); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot set up terminal: %v\n", err)
+		os.Exit(1)
+	}
+	defer stty(saved)
+
+	editor := repl.NewLineEditor(os.Stdin, os.Stdout)
+	editor.Complete = repl.EnvCompleter(&env)
+	if err := editor.LoadHistory(*history); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot load history: %v\n", err)
+	}
+	REPL(&env, editor)
+	if err := editor.SaveHistory(*history); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot save history: %v\n", err)
+	}
 }
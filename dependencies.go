@@ -0,0 +1,155 @@
+package eval
+
+import (
+	"sort"
+
+	"go/ast"
+)
+
+// The Env entries read by a checked expression, as reported by Dependencies.
+// Each list is sorted and contains no duplicates.
+type Deps struct {
+	// Access paths of the variables read. A path starts at a variable,
+	// a package variable such as pkg.V or a field of a WithReceiver scope,
+	// and is extended by struct fields. Indexing is written as [*], as
+	// the index may change between evaluations. For example a.b[i].c is
+	// reported as a.b[*].c, along with any dependencies of i.
+	Vars []string
+
+	// Constants, including package constants such as pkg.C
+	Consts []string
+
+	// Functions which may be called, including package functions such as
+	// pkg.F and methods of a WithReceiver scope. Calls through func valued
+	// variables and method calls on values are covered by Vars.
+	Funcs []string
+
+	// Packages referenced
+	Pkgs []string
+}
+
+// Dependencies reports the variables, constants, functions and packages
+// which expr reads. expr must have been returned by CheckExpr. Predeclared
+// identifiers and types are not reported, nor is anything read by the body
+// of a func literal.
+func Dependencies(expr Expr) *Deps {
+	d := &deps{
+		vars:   map[string]bool{},
+		consts: map[string]bool{},
+		funcs:  map[string]bool{},
+		pkgs:   map[string]bool{},
+	}
+	d.add(expr)
+	return &Deps{
+		Vars:   sortedKeys(d.vars),
+		Consts: sortedKeys(d.consts),
+		Funcs:  sortedKeys(d.funcs),
+		Pkgs:   sortedKeys(d.pkgs),
+	}
+}
+
+type deps struct {
+	vars, consts, funcs, pkgs map[string]bool
+}
+
+func (d *deps) add(expr Expr) {
+	InspectExpr(expr, func(e Expr) bool {
+		if e == nil {
+			return false
+		} else if path, ok := d.path(e); ok {
+			d.vars[path] = true
+			return false
+		}
+		switch e := e.(type) {
+		case *Ident:
+			switch e.source {
+			case envConst:
+				d.consts[e.Name] = true
+			case envFunc, envMethod:
+				d.funcs[e.Name] = true
+			}
+		case *SelectorExpr:
+			if e.pkgName != "" {
+				d.pkgs[e.pkgName] = true
+				name := e.pkgName + "." + e.Sel.Name
+				switch e.Sel.source {
+				case envConst:
+					d.consts[name] = true
+				case envFunc:
+					d.funcs[name] = true
+				}
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Returns the access path of expr if it is rooted at a variable. The
+// dependencies of any index expressions along the path are added to d.
+func (d *deps) path(expr Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *Ident:
+		if e.source == envVar || e.source == envField {
+			return e.Name, true
+		}
+	case *ParenExpr:
+		if x, ok := checkedChild(e.X); ok {
+			return d.path(x)
+		}
+	case *StarExpr:
+		if x, ok := checkedChild(e.X); ok {
+			return d.path(x)
+		}
+	case *SliceExpr:
+		if x, ok := checkedChild(e.X); ok {
+			if path, ok := d.path(x); ok {
+				d.addChildren(e.Low, e.High, e.Max)
+				return path, true
+			}
+		}
+	case *IndexExpr:
+		if x, ok := checkedChild(e.X); ok {
+			if path, ok := d.path(x); ok {
+				d.addChildren(e.Index)
+				return path + "[*]", true
+			}
+		}
+	case *SelectorExpr:
+		if e.pkgName != "" {
+			if e.Sel.source == envVar {
+				d.pkgs[e.pkgName] = true
+				return e.pkgName + "." + e.Sel.Name, true
+			}
+		} else if x, ok := checkedChild(e.X); ok {
+			if path, ok := d.path(x); ok {
+				if e.field != nil {
+					return path + "." + e.Sel.Name, true
+				}
+				// A method value reads its receiver
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (d *deps) addChildren(exprs ...ast.Expr) {
+	for _, x := range exprs {
+		if e, ok := checkedChild(x); ok {
+			d.add(e)
+		}
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
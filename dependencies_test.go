@@ -0,0 +1,65 @@
+package eval
+
+import (
+	"reflect"
+	"testing"
+)
+
+type depsInner struct {
+	C int
+}
+
+type depsOuter struct {
+	B []depsInner
+}
+
+func expectDeps(t *testing.T, expr string, env *Env, expected *Deps) {
+	if actual := Dependencies(getCheckedExpr(t, expr, env)); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Dependencies of '%s' are %+v, expected %+v", expr, *actual, *expected)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	env := makeEnv()
+	pkg := makeEnv()
+	env.Pkgs["strconv"] = pkg
+	pkg.Funcs["Itoa"] = reflect.ValueOf(func(int) string { return "" })
+	pkg.Consts["IntSize"] = reflect.ValueOf(NewConstInt64(64))
+	v := 1
+	pkg.Vars["V"] = reflect.ValueOf(&v)
+
+	a := depsOuter{}
+	i, j := 0, 0
+	p := &SelStruct{}
+	env.Vars["a"] = reflect.ValueOf(&a)
+	env.Vars["i"] = reflect.ValueOf(&i)
+	env.Vars["j"] = reflect.ValueOf(&j)
+	env.Vars["p"] = reflect.ValueOf(&p)
+	env.Consts["K"] = reflect.ValueOf(NewConstInt64(2))
+	env.Funcs["f"] = reflect.ValueOf(func(int) int { return 0 })
+
+	expectDeps(t, "a.B[i].C", env, &Deps{Vars: []string{"a.B[*].C", "i"}})
+	expectDeps(t, "a.B[f(j)].C + K", env, &Deps{
+		Vars:   []string{"a.B[*].C", "j"},
+		Consts: []string{"K"},
+		Funcs:  []string{"f"},
+	})
+	expectDeps(t, "(*p).F() + len(a.B[1:i])", env, &Deps{Vars: []string{"a.B", "i", "p"}})
+	expectDeps(t, "strconv.Itoa(strconv.V + strconv.IntSize)", env, &Deps{
+		Vars:   []string{"strconv.V"},
+		Consts: []string{"strconv.IntSize"},
+		Funcs:  []string{"strconv.Itoa"},
+		Pkgs:   []string{"strconv"},
+	})
+	expectDeps(t, "true", env, &Deps{})
+}
+
+func TestDependenciesReceiver(t *testing.T) {
+	env := NewEnv()
+	recv := depsOuter{}
+	env, err := WithReceiver(env, &recv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectDeps(t, "len(B[0:1])", env, &Deps{Vars: []string{"B"}})
+}
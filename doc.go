@@ -3,5 +3,8 @@
 // The main entry point is:
 //  func EvalExpr(ctx *Ctx, expr ast.Expr, env *Env)
 //    (*[]reflect.Value, bool, error)
+//
+// Eval, EvalString and EvalAs parse, check and evaluate an expression
+// string in a single call.
 
 package eval
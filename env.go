@@ -2,6 +2,7 @@ package eval
 
 import (
 	"reflect"
+	"sync"
 )
 
 type Pkg *Env
@@ -12,12 +13,18 @@ const (
 	envVar
 	envConst
 	envFunc
+	envField
+	envMethod
 )
 
 // A Environment used for evaluation
 type Env struct {
 	Name string  // e.g "fmt"
-	Path string  // e.g. "github.com/0xfaded/eval"
+
+	// Import path, e.g. "github.com/0xfaded/eval". As in Go, composite
+	// literals may set the unexported fields only of struct types from
+	// this package, "main" if Path is empty.
+	Path string
 
 	// Values
 	Vars map[string] reflect.Value
@@ -29,4 +36,79 @@ type Env struct {
 
 	// Packages
 	Pkgs map[string] Pkg
+
+	// Enclosing scope, consulted for names not bound in this Env. The
+	// outermost scope has a nil Parent, and is itself enclosed by the
+	// universe scope of predeclared identifiers.
+	Parent *Env
+
+	// Pointer to the value whose fields and methods are in scope, set by
+	// WithReceiver
+	receiver reflect.Value
+
+	// The call of an interpreted function which this scope is the
+	// outermost of
+	frame *frame
+}
+
+// Goroutines started by go statements evaluate identifiers while the host
+// may bind new names, such as through EvalDecls. Bindings made by this
+// package are written under envMu, and evaluation reads them under its
+// read lock. Hosts writing to the maps of an Env directly while interpreted
+// goroutines run must do the same through the Set methods.
+var envMu sync.RWMutex
+
+// NewScope creates an empty Env nested in parent. Names bound in the new
+// scope shadow those of the same name in parent and its ancestors, as in
+// a Go block. A debugger might nest locals in closure variables, nested in
+// package globals. If parent is nil, the scope is a root, as from NewEnv.
+func NewScope(parent *Env) *Env {
+	env := NewEnv()
+	if parent == nil {
+		return env
+	}
+	env.Name = parent.Name
+	env.Path = parent.Path
+	env.Parent = parent
+	return env
+}
+
+// Find the innermost scope, starting from env, which binds name, along with
+// the number of Parent links followed to reach it. Returns nil if no scope
+// binds name.
+func (env *Env) lookupScope(name string) (_ *Env, depth int) {
+	for ; env != nil; env, depth = env.Parent, depth + 1 {
+		if env.declares(name) {
+			return env, depth
+		}
+	}
+	return nil, 0
+}
+
+// The frame of the innermost interpreted function call enclosing env, or
+// nil if env is not within one
+func (env *Env) callFrame() *frame {
+	for ; env != nil; env = env.Parent {
+		if env.frame != nil {
+			return env.frame
+		}
+	}
+	return nil
+}
+
+// The scope depth Parent links above env
+func (env *Env) ancestor(depth int) *Env {
+	for ; depth > 0; depth -= 1 {
+		env = env.Parent
+	}
+	return env
+}
+
+// Find the package called name, and the depth of the scope binding it
+func (env *Env) lookupPkg(name string) (Pkg, int, bool) {
+	if scope, depth := env.lookupScope(name); scope != nil {
+		pkg, ok := scope.Pkgs[name]
+		return pkg, depth, ok
+	}
+	return nil, 0, false
 }
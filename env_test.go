@@ -0,0 +1,72 @@
+package eval
+
+import (
+	"reflect"
+	"testing"
+
+	"go/parser"
+)
+
+func TestScopeLookup(t *testing.T) {
+	global := makeEnv()
+	x, y := 1, 2
+	global.Vars["x"] = reflect.ValueOf(&x)
+	global.Vars["y"] = reflect.ValueOf(&y)
+	global.Funcs["double"] = reflect.ValueOf(func(i int) int { return i * 2 })
+	pkg := makeEnv()
+	pkg.Consts["K"] = reflect.ValueOf(10)
+	global.Pkgs["p"] = pkg
+
+	local := NewScope(NewScope(global))
+	lx := "shadow"
+	local.Vars["x"] = reflect.ValueOf(&lx)
+
+	expectResult(t, "x", local, "shadow")
+	expectResult(t, "double(y) + p.K", local, 14)
+	expectResult(t, "x", global, 1)
+
+	root := NewScope(nil)
+	if root.Parent != nil {
+		t.Fatalf("NewScope(nil) has parent %v", root.Parent)
+	}
+	root.Vars["x"] = reflect.ValueOf(&x)
+	expectResult(t, "x", root, 1)
+}
+
+func TestScopeShadowKinds(t *testing.T) {
+	global := makeEnv()
+	global.Types["T"] = reflect.TypeOf(0)
+	global.Pkgs["p"] = makeEnv()
+	local := NewScope(global)
+	i, j := 3, 4
+	local.Vars["T"] = reflect.ValueOf(&i)
+	local.Vars["p"] = reflect.ValueOf(&j)
+
+	expectResult(t, "T + p", local, 7)
+	expectConst(t, "T(1)", global, 1, reflect.TypeOf(0))
+}
+
+func TestScopeResolutionDepth(t *testing.T) {
+	global := makeEnv()
+	x := 1
+	global.Vars["x"] = reflect.ValueOf(&x)
+
+	expr := "x"
+	ctx := &Ctx{Input: expr}
+	e, _ := parser.ParseExpr(expr)
+	aexpr, errs := CheckExpr(ctx, e, NewScope(global))
+	if errs != nil {
+		t.Fatalf("Failed to check expression '%s' (%v)", expr, errs)
+	}
+
+	// Evaluating in a new frame with the same shape uses the outer x,
+	// even though the frame binds x itself.
+	frame := NewScope(global)
+	shadow := 2
+	frame.Vars["x"] = reflect.ValueOf(&shadow)
+	if v, _, err := EvalExpr(ctx, aexpr, frame); err != nil {
+		t.Fatalf("Error evaluating expression '%s' (%v)", expr, err)
+	} else if (*v)[0].Int() != 1 {
+		t.Fatalf("Expected x to be resolved in the parent scope, got %v", (*v)[0])
+	}
+}
@@ -0,0 +1,189 @@
+package eval
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"go/constant"
+	"go/token"
+)
+
+// NewEnv creates an empty Env, ready to be populated with SetVar, SetConst,
+// SetFunc and SetType.
+func NewEnv() *Env {
+	return &Env {
+		Vars: make(map[string] reflect.Value),
+		Consts: make(map[string] reflect.Value),
+		Funcs: make(map[string] reflect.Value),
+		Types: make(map[string] reflect.Type),
+		Pkgs: make(map[string] Pkg),
+	}
+}
+
+// SetVar binds name to the variable pointed to by ptr, e.g. SetVar("x", &x).
+// Evaluation reads x through the pointer, so later changes to x are seen.
+func (env *Env) SetVar(name string, ptr interface{}) error {
+	if err := checkEnvName("SetVar", name); err != nil {
+		return err
+	}
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("eval: SetVar %s: expected a non-nil pointer, not %T", name, ptr)
+	}
+	envMu.Lock()
+	env.Vars[name] = v
+	envMu.Unlock()
+	return nil
+}
+
+// SetConst binds name to the constant c. Numbers of type *big.Int,
+// *big.Rat, *big.Float or *ConstNumber become untyped constants, like
+// Go's 1 << 100, as do values of the default types int, rune, float64 and
+// complex128, so SetConst("N", 10) is like const N = 10. Any other
+// boolean, numeric or string value becomes a constant of its own type,
+// such as time.Second or int8(3).
+func (env *Env) SetConst(name string, c interface{}) error {
+	if err := checkEnvName("SetConst", name); err != nil {
+		return err
+	}
+	var n *ConstNumber
+	switch c := c.(type) {
+	case *ConstNumber:
+		n = c
+	case *big.Int:
+		n = &ConstNumber{constant.Make(new(big.Int).Set(c)), ConstInt}
+	case *big.Rat:
+		n = &ConstNumber{constant.Make(new(big.Rat).Set(c)), ConstFloat}
+	case *big.Float:
+		if c.IsInf() {
+			return fmt.Errorf("eval: SetConst %s: constant is infinite", name)
+		}
+		n = &ConstNumber{constant.Make(new(big.Float).Copy(c)), ConstFloat}
+	case int:
+		n = NewConstInt64(int64(c))
+	case rune:
+		n = NewConstRune(c)
+	case float64:
+		n = NewConstFloat64(c)
+	case complex128:
+		n = NewConstComplex128(c)
+	}
+	if n != nil && n.Value.Kind() == constant.Unknown {
+		return fmt.Errorf("eval: SetConst %s: constant %v is not finite", name, c)
+	}
+	if n != nil {
+		envMu.Lock()
+		env.Consts[name] = reflect.ValueOf(n)
+		envMu.Unlock()
+		return nil
+	}
+
+	v := reflect.ValueOf(c)
+	switch v.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		envMu.Lock()
+		env.Consts[name] = v
+		envMu.Unlock()
+		return nil
+	}
+	return fmt.Errorf("eval: SetConst %s: %T is not a constant type", name, c)
+}
+
+// SetFunc binds name to the function f.
+func (env *Env) SetFunc(name string, f interface{}) error {
+	if err := checkEnvName("SetFunc", name); err != nil {
+		return err
+	}
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Errorf("eval: SetFunc %s: expected a non-nil func, not %T", name, f)
+	}
+	envMu.Lock()
+	env.Funcs[name] = v
+	envMu.Unlock()
+	return nil
+}
+
+// SetType binds name to the type T, given as a nil pointer (*T)(nil). This
+// allows interface types to be bound. A reflect.Type is also accepted.
+func (env *Env) SetType(name string, ptr interface{}) error {
+	if err := checkEnvName("SetType", name); err != nil {
+		return err
+	}
+	if t, ok := ptr.(reflect.Type); ok {
+		envMu.Lock()
+		env.Types[name] = t
+		envMu.Unlock()
+		return nil
+	}
+	t := reflect.TypeOf(ptr)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return fmt.Errorf("eval: SetType %s: expected a pointer such as (*T)(nil), not %T", name, ptr)
+	}
+	envMu.Lock()
+	env.Types[name] = t.Elem()
+	envMu.Unlock()
+	return nil
+}
+
+// EnvFromStruct creates an Env with a variable for each exported field of
+// the struct pointed to by ptr, including fields promoted from embedded
+// structs. The variables alias the fields, so evaluation sees changes to
+// the struct. Fields promoted through nil embedded pointers are skipped.
+func EnvFromStruct(ptr interface{}) (*Env, error) {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("eval: EnvFromStruct: expected a non-nil pointer to a struct, not %T", ptr)
+	}
+	env := NewEnv()
+	s := v.Elem()
+	for _, field := range reflect.VisibleFields(s.Type()) {
+		if !field.IsExported() {
+			continue
+		}
+		// Skip fields hidden by, or ambiguous with, another field
+		if f, _ := s.Type().FieldByName(field.Name); !reflect.DeepEqual(f.Index, field.Index) {
+			continue
+		}
+		if fv, err := s.FieldByIndexErr(field.Index); err == nil {
+			env.Vars[field.Name] = fv.Addr()
+		}
+	}
+	return env, nil
+}
+
+// EnvFromMap creates an Env from the named values in m. Functions are bound
+// as by SetFunc, and all other values become variables holding a copy of
+// the value. Use SetVar to bind a variable by reference.
+func EnvFromMap(m map[string]interface{}) (*Env, error) {
+	env := NewEnv()
+	for name, x := range m {
+		if err := checkEnvName("EnvFromMap", name); err != nil {
+			return nil, err
+		}
+		v := reflect.ValueOf(x)
+		if !v.IsValid() {
+			return nil, fmt.Errorf("eval: EnvFromMap %s: value has no type", name)
+		} else if v.Kind() == reflect.Func {
+			if err := env.SetFunc(name, x); err != nil {
+				return nil, err
+			}
+		} else {
+			ptr := reflect.New(v.Type())
+			ptr.Elem().Set(v)
+			env.Vars[name] = ptr
+		}
+	}
+	return env, nil
+}
+
+func checkEnvName(op, name string) error {
+	if !token.IsIdentifier(name) || name == "_" {
+		return fmt.Errorf("eval: %s: %q is not a valid identifier", op, name)
+	}
+	return nil
+}
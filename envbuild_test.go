@@ -0,0 +1,121 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestEnvSetters(t *testing.T) {
+	env := NewEnv()
+	x := 2
+	big := new(big.Int).Lsh(big.NewInt(1), 100)
+	if err := env.SetVar("x", &x); err != nil {
+		t.Fatal(err)
+	} else if err := env.SetConst("Big", big); err != nil {
+		t.Fatal(err)
+	} else if err := env.SetConst("K", int8(3)); err != nil {
+		t.Fatal(err)
+	} else if err := env.SetFunc("double", func(i int) int { return i * 2 }); err != nil {
+		t.Fatal(err)
+	} else if err := env.SetType("Stringer", (*fmt.Stringer)(nil)); err != nil {
+		t.Fatal(err)
+	} else if err := env.SetType("SelInt", reflect.TypeOf(SelInt(0))); err != nil {
+		t.Fatal(err)
+	}
+
+	expectResult(t, "double(x)", env, 4)
+	expectConst(t, "Big / Big * 4", env, NewConstInt64(4), ConstInt)
+	expectConst(t, "K + 1", env, int8(4), i8)
+	expectResult(t, "Stringer(nil) == nil", env, true)
+	expectResult(t, "SelInt(1).E()", env, 1)
+
+	x = 5
+	expectResult(t, "x", env, 5)
+}
+
+func TestEnvSetConstUntyped(t *testing.T) {
+	env := NewEnv()
+	env.SetConst("N", 10)
+	env.SetConst("R", 'a')
+	env.SetConst("F", 2.5)
+	env.SetConst("C", 1i)
+
+	expectConst(t, "N / 4", env, NewConstInt64(2), ConstInt)
+	expectConst(t, "int8(N)", env, int8(10), i8)
+	expectConst(t, "R + 1", env, NewConstRune('b'), ConstRune)
+	expectConst(t, "F * 2", env, NewConstFloat64(5), ConstFloat)
+	expectConst(t, "C * C", env, NewConstInt64(-1), ConstComplex)
+	if err := env.SetConst("NaN", math.NaN()); err == nil {
+		t.Fatalf("Expected an error for a NaN constant")
+	}
+}
+
+func TestEnvSetterErrors(t *testing.T) {
+	env := NewEnv()
+	x := 1
+	var nilFunc func()
+	errs := []error{
+		env.SetVar("x", x),
+		env.SetVar("x", (*int)(nil)),
+		env.SetVar("1x", &x),
+		env.SetConst("c", []int{}),
+		env.SetFunc("f", nilFunc),
+		env.SetFunc("f", x),
+		env.SetType("T", x),
+	}
+	for i, err := range errs {
+		if err == nil {
+			t.Errorf("%d. Expected an error", i)
+		}
+	}
+	if err := env.SetVar("x", x); err.Error() != "eval: SetVar x: expected a non-nil pointer, not int" {
+		t.Errorf("Unexpected error message `%v`", err)
+	}
+}
+
+func TestEnvFromStruct(t *testing.T) {
+	type Inner struct{ C, D, E int }
+	type Outer struct {
+		A int
+		b int
+		Inner
+		*SelNested
+		E string
+	}
+	s := Outer{A: 1, b: 2, Inner: Inner{C: 3, D: 4, E: 5}, E: "e"}
+	env, err := EnvFromStruct(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, "A + C + Inner.D", env, 8)
+	expectResult(t, "E", env, "e")
+	expectCheckError(t, "b", env, "undefined: b")
+	// Ambiguous between Inner.D and SelNested.D
+	expectCheckError(t, "D", env, "undefined: D")
+
+	s.A = 10
+	expectResult(t, "A", env, 10)
+
+	if _, err := EnvFromStruct(s); err == nil {
+		t.Fatalf("Expected error for non-pointer")
+	}
+}
+
+func TestEnvFromMap(t *testing.T) {
+	env, err := EnvFromMap(map[string]interface{}{
+		"a":      1,
+		"s":      "str",
+		"double": func(i int) int { return i * 2 },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, "double(a) + len(s)", env, 5)
+
+	if _, err := EnvFromMap(map[string]interface{}{"n": nil}); err == nil {
+		t.Fatalf("Expected error for nil value")
+	}
+}
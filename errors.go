@@ -16,6 +16,10 @@ type ErrUndefined struct {
 	ErrorContext
 }
 
+type ErrBuiltinNotCalled struct {
+	ErrorContext
+}
+
 type ErrInvalidIndirect struct {
 	ErrorContext
 }
@@ -24,6 +28,16 @@ type ErrUndefinedFieldOrMethod struct {
 	ErrorContext
 }
 
+type ErrUnexportedFieldSelector struct {
+	ErrorContext
+}
+
+type ErrUndefinedMethodExpr struct {
+	ErrorContext
+	t reflect.Type
+	needsPtr bool
+}
+
 type ErrCallNonFuncType struct {
 	ErrorContext
 }
@@ -43,6 +57,18 @@ type ErrInvalidEllipsisInCall struct {
 	ErrorContext
 }
 
+type ErrInvalidArrayLen struct {
+	ErrorContext
+}
+
+type ErrInvalidEmbeddedField struct {
+	ErrorContext
+}
+
+type ErrDuplicateField struct {
+	ErrorContext
+}
+
 type ErrMissingValue struct {
 	ErrorContext
 }
@@ -113,6 +139,9 @@ type ErrBadConversion struct {
 	from reflect.Type
 	to reflect.Type
 	v reflect.Value
+
+	// how to is spelled, if with the alias byte or rune
+	spelling string
 }
 
 type ErrBadConstConversion struct {
@@ -120,6 +149,9 @@ type ErrBadConstConversion struct {
 	from reflect.Type
 	to reflect.Type
 	v reflect.Value
+
+	// how to is spelled, if with the alias byte or rune
+	spelling string
 }
 
 type ErrTruncatedConstant struct {
@@ -133,6 +165,9 @@ type ErrOverflowedConstant struct {
 	from ConstType
 	to reflect.Type
 	constant *ConstNumber
+
+	// how to is spelled, if with the alias byte or rune
+	spelling string
 }
 
 type ErrUntypedNil struct {
@@ -186,6 +221,11 @@ type ErrBadArrayValue struct {
 	eltT reflect.Type
 }
 
+type ErrBadResultType struct {
+	ErrorContext
+	resultT reflect.Type
+}
+
 type ErrUnknownStructField struct {
 	ErrorContext
 	structT reflect.Type
@@ -196,6 +236,12 @@ type ErrInvalidStructField struct {
 	ErrorContext
 }
 
+type ErrImplicitUnexportedField struct {
+	ErrorContext
+	structT reflect.Type
+	field string
+}
+
 type ErrDuplicateStructField struct {
 	ErrorContext
 	field string
@@ -244,6 +290,10 @@ type ErrBuiltinNonTypeArg struct {
 	ErrorContext
 }
 
+type ErrCloseRecvOnlyChan struct {
+	ErrorContext
+}
+
 type ErrBuiltinInvalidEllipsis struct {
 	ErrorContext
 }
@@ -285,6 +335,152 @@ type ErrDeleteFirstArgNotMap struct {
 	ErrorContext
 }
 
+type ErrSideEffect struct {
+	ErrorContext
+	what string
+}
+
+type ErrCallDenied struct {
+	ErrorContext
+	callee Callee
+}
+
+type ErrConstInitNotConst struct {
+	ErrorContext
+}
+
+type ErrInvalidConstType struct {
+	ErrorContext
+	t reflect.Type
+}
+
+type ErrConstTypeWithoutExpr struct {
+	ErrorContext
+}
+
+type ErrMissingInitExpr struct {
+	ErrorContext
+}
+
+type ErrExtraInitExpr struct {
+	ErrorContext
+}
+
+type ErrAssignCountMismatch struct {
+	ErrorContext
+	lhs, rhs int
+}
+
+type ErrBadAssignType struct {
+	ErrorContext
+	from, to reflect.Type
+}
+
+type ErrUnusedExpr struct {
+	ErrorContext
+}
+
+type ErrCannotAssign struct {
+	ErrorContext
+}
+
+type ErrNonNameDefine struct {
+	ErrorContext
+}
+
+type ErrRepeatedDefine struct {
+	ErrorContext
+}
+
+type ErrNoNewVars struct {
+	ErrorContext
+}
+
+type ErrWrongResultCount struct {
+	ErrorContext
+	want, have int
+}
+
+type ErrMissingReturn struct {
+	ErrorContext
+}
+
+type ErrNonBoolCondition struct {
+	ErrorContext
+	what string
+}
+
+type ErrCannotRange struct {
+	ErrorContext
+	t reflect.Type
+}
+
+type ErrRangeTooManyVars struct {
+	ErrorContext
+	t reflect.Type
+}
+
+type ErrInvalidCase struct {
+	ErrorContext
+	tagT reflect.Type
+}
+
+type ErrMultipleDefaults struct {
+	ErrorContext
+	what string
+}
+
+type ErrFallthroughFinalCase struct {
+	ErrorContext
+}
+
+type ErrMisplacedFallthrough struct {
+	ErrorContext
+}
+
+type ErrInvalidBranch struct {
+	ErrorContext
+}
+
+type ErrInvalidSendTo struct {
+	ErrorContext
+}
+
+type ErrBadSendType struct {
+	ErrorContext
+	from, to reflect.Type
+}
+
+type ErrInvalidSelectCase struct {
+	ErrorContext
+}
+
+type ErrConstOverflow struct {
+	ErrorContext
+}
+
+type ErrInvalidShiftCount struct {
+	ErrorContext
+}
+
+type ErrNegativeShiftCount struct {
+	ErrorContext
+}
+
+type ErrInvalidShiftOperand struct {
+	ErrorContext
+}
+
+type ErrNotCall struct {
+	ErrorContext
+	what string
+}
+
+type ErrDiscardedResult struct {
+	ErrorContext
+	what string
+}
+
 type ErrorContext struct {
 	Input string
 	ast.Node
@@ -298,6 +494,10 @@ func (err ErrUndefined) Error() string {
 	return fmt.Sprintf("undefined: %v", err.Node)
 }
 
+func (err ErrBuiltinNotCalled) Error() string {
+	return fmt.Sprintf("use of builtin %v not in function call", err.Node)
+}
+
 func (err ErrInvalidIndexOperation) Error() string {
 	t := err.Node.(*IndexExpr).X.(Expr).KnownType()[0]
 	return fmt.Sprintf("invalid operation: %s (index of type %v)", err.Source(), t)
@@ -338,6 +538,22 @@ func (err ErrUndefinedFieldOrMethod) Error() string {
 		selector, t, selector.Sel.Name)
 }
 
+func (err ErrUnexportedFieldSelector) Error() string {
+	selector := err.Node.(*SelectorExpr)
+	return fmt.Sprintf("%v undefined (cannot refer to unexported field %v)",
+		selector, selector.Sel.Name)
+}
+
+func (err ErrUndefinedMethodExpr) Error() string {
+	selector := err.Node.(*SelectorExpr)
+	if err.needsPtr {
+		return fmt.Sprintf("invalid method expression %v (needs pointer receiver: (*%v).%v)",
+			selector, err.t, selector.Sel.Name)
+	}
+	return fmt.Sprintf("%v undefined (type %v has no method %v)",
+		selector, err.t, selector.Sel.Name)
+}
+
 func (err ErrMissingValue) Error() string {
 	return fmt.Sprintf("%s used as value", err.ErrorContext.Source())
 }
@@ -442,6 +658,18 @@ func (err ErrInvalidEllipsisInCall) Error() string {
 	return fmt.Sprintf("invalid use of ... in call to %v", fun)
 }
 
+func (err ErrInvalidArrayLen) Error() string {
+	return fmt.Sprintf("invalid array bound %s", err.Source())
+}
+
+func (err ErrInvalidEmbeddedField) Error() string {
+	return fmt.Sprintf("invalid embedded field type %s", err.Source())
+}
+
+func (err ErrDuplicateField) Error() string {
+	return fmt.Sprintf("duplicate field %v", err.Node)
+}
+
 func (err ErrInvalidUnaryOperation) Error() string {
 	unary := err.ErrorContext.Node.(*UnaryExpr)
 	x := unary.X.(Expr)
@@ -579,7 +807,7 @@ func (err ErrInvalidBinaryOperation) Error() string {
                 yi = sprintUntypedConstAsTyped(y)
         }
         // One last hack to display nil types as "nil", not the usual "<T>"
-        var xti, yti interface{} = xt, yt
+        var xti, yti interface{} = spellType(xt, typeSpelling(x)), spellType(yt, typeSpelling(y))
         if !ycok && xt == ConstNil {
                 xti = "nil"
         }
@@ -596,11 +824,11 @@ func (err ErrDivideByZero) Error() string {
 }
 
 func (err ErrBadConversion) Error() string {
-	return fmt.Sprintf("cannot convert %v (type %v) to type %v", err.Node.(Expr), err.from, err.to)
+	return fmt.Sprintf("cannot convert %v (type %v) to type %v", err.Node.(Expr), err.from, spellType(err.to, err.spelling))
 }
 
 func (err ErrBadConstConversion) Error() string {
-	return fmt.Sprintf("cannot convert %v to type %v", err.Node.(Expr), err.to)
+	return fmt.Sprintf("cannot convert %v to type %v", err.Node.(Expr), spellType(err.to, err.spelling))
 }
 
 func (err ErrTruncatedConstant) Error() string {
@@ -620,12 +848,12 @@ func (err ErrOverflowedConstant) Error() string {
 
 		// Runes print their actual value in overflow errors
 		if err.constant.Type == ConstRune {
-			constant = err.constant.Value.Re.Num().String()
+			constant = err.constant.Value.ExactString()
 		} else {
 			constant = err.constant.String()
 		}
 
-		return fmt.Sprintf("constant %v overflows %v", constant, err.to)
+		return fmt.Sprintf("constant %v overflows %v", constant, spellType(err.to, err.spelling))
 	}
 }
 
@@ -694,11 +922,26 @@ func (err ErrBadArrayValue) Error() string {
 		expr, t, err.eltT)
 }
 
+func (err ErrBadResultType) Error() string {
+	expr := err.Node.(Expr)
+	t := expr.KnownType()[0]
+	if t == ConstNil {
+		return fmt.Sprintf("cannot use nil as type %v in result", err.resultT)
+	}
+	return fmt.Sprintf("cannot use %v (type %v) as type %v in result",
+		expr, t, err.resultT)
+}
+
 func (err ErrUnknownStructField) Error() string {
 	return fmt.Sprintf("unknown %v field '%v' in struct literal",
 		err.structT, err.field)
 }
 
+func (err ErrImplicitUnexportedField) Error() string {
+	return fmt.Sprintf("implicit assignment of unexported field '%v' in %v literal",
+		err.field, err.structT)
+}
+
 func (err ErrInvalidStructField) Error() string {
 	return fmt.Sprintf("invalid field name %v in struct initializer", err.Node)
 }
@@ -883,6 +1126,10 @@ func (err ErrBuiltinInvalidEllipsis) Error() string {
 	return fmt.Sprintf("invalid use of ... with builtin %s", ident.Name)
 }
 
+func (err ErrCloseRecvOnlyChan) Error() string {
+	return fmt.Sprintf("invalid operation: %v (cannot close receive-only channel)", uc(err.Node.(Expr)))
+}
+
 func (err ErrMakeBadType) Error() string {
 	return "TODO ErrMakeBadType"
 }
@@ -947,10 +1194,190 @@ func (err ErrDeleteFirstArgNotMap) Error() string {
 	return fmt.Sprintf("first argument to delete must be map; have %s", s)
 }
 
+func (err ErrSideEffect) Error() string {
+	return fmt.Sprintf("%s not allowed in read-only mode: %s", err.what, err.Source())
+}
+
+func (err ErrCallDenied) Error() string {
+	return fmt.Sprintf("%s not allowed by policy", err.Source())
+}
+
 func at(ctx *Ctx, expr ast.Node) ErrorContext {
 	return ErrorContext{ctx.Input, expr}
 }
 
+func (err ErrConstInitNotConst) Error() string {
+	return fmt.Sprintf("const initializer %s is not a constant", err.Source())
+}
+
+func (err ErrInvalidConstType) Error() string {
+	return fmt.Sprintf("invalid constant type %v", err.t)
+}
+
+func (ErrConstTypeWithoutExpr) Error() string {
+	return "const declaration cannot have type without expression"
+}
+
+func (ErrMissingInitExpr) Error() string {
+	return "missing init expr for const declaration"
+}
+
+func (ErrExtraInitExpr) Error() string {
+	return "extra init expr"
+}
+
+func (err ErrAssignCountMismatch) Error() string {
+	return fmt.Sprintf("assignment count mismatch: %d = %d", err.lhs, err.rhs)
+}
+
+func (err ErrBadAssignType) Error() string {
+	if err.from == ConstNil {
+		return fmt.Sprintf("cannot use nil as type %v in assignment", err.to)
+	}
+	return fmt.Sprintf("cannot use %s (type %v) as type %v in assignment",
+		err.Source(), err.from, err.to)
+}
+
+func (err ErrUnusedExpr) Error() string {
+	return fmt.Sprintf("%s evaluated but not used", err.Source())
+}
+
+func (err ErrCannotAssign) Error() string {
+	return fmt.Sprintf("cannot assign to %s", err.Source())
+}
+
+func (err ErrNonNameDefine) Error() string {
+	return fmt.Sprintf("non-name %s on left side of :=", err.Source())
+}
+
+func (err ErrRepeatedDefine) Error() string {
+	return fmt.Sprintf("%s repeated on left side of :=", err.Source())
+}
+
+func (ErrNoNewVars) Error() string {
+	return "no new variables on left side of :="
+}
+
+func (err ErrWrongResultCount) Error() string {
+	if err.have > err.want {
+		return "too many arguments to return"
+	}
+	return "not enough arguments to return"
+}
+
+func (ErrMissingReturn) Error() string {
+	return "missing return at end of function"
+}
+
+func (err ErrNonBoolCondition) Error() string {
+	x := err.Node.(Expr)
+	return fmt.Sprintf("non-bool %s (type %v) used as %s condition",
+		err.Source(), sprintOperandType(x.KnownType()[0]), err.what)
+}
+
+func (err ErrCannotRange) Error() string {
+	return fmt.Sprintf("cannot range over %s (type %v)", err.Source(), sprintOperandType(err.t))
+}
+
+func (err ErrRangeTooManyVars) Error() string {
+	return fmt.Sprintf("range over %v permits only one iteration variable", err.t)
+}
+
+func (err ErrInvalidCase) Error() string {
+	x := err.Node.(Expr)
+	if err.tagT != boolType && !isStaticTypeComparable(err.tagT) {
+		return fmt.Sprintf("invalid case %s in switch (can only compare %v %s to nil)",
+			err.Source(), err.tagT.Kind(), err.Source())
+	}
+	return fmt.Sprintf("invalid case %s in switch (mismatched types %v and %v)",
+		err.Source(), sprintOperandType(x.KnownType()[0]), err.tagT)
+}
+
+func (err ErrMultipleDefaults) Error() string {
+	return "multiple defaults in " + err.what
+}
+
+func (ErrFallthroughFinalCase) Error() string {
+	return "cannot fallthrough final case in switch"
+}
+
+func (ErrMisplacedFallthrough) Error() string {
+	return "fallthrough statement out of place"
+}
+
+func (err ErrInvalidBranch) Error() string {
+	b := err.Node.(*ast.BranchStmt)
+	if b.Label != nil {
+		return fmt.Sprintf("invalid %s label %s", b.Tok, b.Label.Name)
+	} else if b.Tok == token.CONTINUE {
+		return "continue is not in a loop"
+	}
+	return "break is not in a loop, switch, or select"
+}
+
+func (err ErrInvalidSendTo) Error() string {
+	t := err.Node.(Expr).KnownType()[0]
+	var cause string
+	if t.Kind() != reflect.Chan {
+		cause = fmt.Sprintf("send to non-chan type %v", t)
+	} else {
+		cause = fmt.Sprintf("send to receive-only type %v", t)
+	}
+	return fmt.Sprintf("invalid operation: %v <- (%s)", err.Node, cause)
+}
+
+func (err ErrBadSendType) Error() string {
+	if err.from == ConstNil {
+		return fmt.Sprintf("cannot use nil as type %v in send", err.to)
+	}
+	return fmt.Sprintf("cannot use %s (type %v) as type %v in send",
+		err.Source(), err.from, err.to)
+}
+
+func (ErrInvalidSelectCase) Error() string {
+	return "select case must be receive, send or assign recv"
+}
+
+func (err ErrConstOverflow) Error() string {
+	// Named as by gc
+	op := ""
+	if binary, ok := err.Node.(*BinaryExpr); ok {
+		switch binary.Op {
+		case token.ADD:
+			op = "addition "
+		case token.SUB:
+			op = "subtraction "
+		case token.MUL:
+			op = "multiplication "
+		case token.XOR:
+			op = "bitwise XOR "
+		case token.SHL:
+			op = "shift "
+		}
+	}
+	return fmt.Sprintf("constant %soverflow", op)
+}
+
+func (err ErrInvalidShiftCount) Error() string {
+	return fmt.Sprintf("invalid shift count %s", err.Source())
+}
+
+func (err ErrNegativeShiftCount) Error() string {
+	return fmt.Sprintf("invalid negative shift count: %s", err.Source())
+}
+
+func (err ErrInvalidShiftOperand) Error() string {
+	return fmt.Sprintf("invalid operation: shifted operand %s must be integer", err.Source())
+}
+
+func (err ErrNotCall) Error() string {
+	return fmt.Sprintf("expression in %s must be function call", err.what)
+}
+
+func (err ErrDiscardedResult) Error() string {
+	return fmt.Sprintf("%s discards result of %s", err.what, err.Source())
+}
+
 func (errCtx ErrorContext) Source() string {
 	return errCtx.Input[errCtx.Node.Pos()-1:errCtx.Node.End()-1]
 }
@@ -963,15 +1390,55 @@ func sprintUntypedConstAsTyped(expr Expr) string {
         }
         switch expr.KnownType()[0].(type) {
         case ConstRuneType:
-                return sprintConstValue(RuneType, reflect.Value(expr.Const()), false)
+                // Even when it is larger than 32 bits
+                n := expr.Const().Interface().(*ConstNumber)
+                return fmt.Sprintf("rune(%v)", formatConstComplex(n.Value, false))
         default:
                 return expr.String()
         }
 }
 
+// Spells t as spelling in errs which report a conversion to t.
+func spellErrors(errs []error, t reflect.Type, spelling string) {
+	if spelling == "" {
+		return
+	}
+	for i, err := range errs {
+		switch e := err.(type) {
+		case ErrBadConversion:
+			if e.to == t && e.spelling == "" {
+				e.spelling = spelling
+				errs[i] = e
+			}
+		case ErrBadConstConversion:
+			if e.to == t && e.spelling == "" {
+				e.spelling = spelling
+				errs[i] = e
+			}
+		case ErrOverflowedConstant:
+			if e.to == t && e.spelling == "" {
+				e.spelling = spelling
+				errs[i] = e
+			}
+		}
+	}
+}
+
+// Spells conversions to the type of the typed operand of binary as that
+// operand is spelled, in the errors errs[from:] produced checking binary
+// itself. The left operand takes precedence.
+func spellBinaryErrors(binary *BinaryExpr, errs []error, from int) []error {
+	x := binary.X.(Expr)
+	if _, ok := x.KnownType()[0].(ConstType); ok {
+		x = binary.Y.(Expr)
+	}
+	spellErrors(errs[from:], x.KnownType()[0], typeSpelling(x))
+	return errs
+}
+
 // Determines if two types can be automatically converted between.
 func areTypesCompatible(xt, yt reflect.Type) bool {
-	return xt.AssignableTo(unhackType(yt)) || yt.AssignableTo(unhackType(xt))
+	return xt.AssignableTo(yt) || yt.AssignableTo(xt)
 }
 
 func sprintOperandType(t reflect.Type) string {
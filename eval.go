@@ -0,0 +1,190 @@
+package eval
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go/parser"
+)
+
+// CheckErrors collects the errors produced by CheckExpr into a single
+// error, as returned by Eval. The individual errors retain their position
+// in the source and are available through Unwrap.
+type CheckErrors []error
+
+func (errs CheckErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (errs CheckErrors) Unwrap() []error {
+	return []error(errs)
+}
+
+// Results holds the values of an expression evaluated by Eval. The typed
+// accessors expect a single result, and return an error rather than
+// panicking if its kind does not match.
+type Results []reflect.Value
+
+// Eval parses, checks and evaluates the expression src in env. Parse
+// errors are returned as is, check errors are joined into CheckErrors and
+// runtime panics are returned as the Panic error they raised.
+//
+// Untyped constant results are converted to their default type, so the
+// result of 1 << 2 is an int and 'a' is a rune.
+func Eval(src string, env *Env) (Results, error) {
+	return EvalCtx(&Ctx{Input: src}, env)
+}
+
+// EvalCtx is Eval for the expression in ctx.Input, evaluated under the
+// options set in ctx.
+func EvalCtx(ctx *Ctx, env *Env) (Results, error) {
+	expr, err := parser.ParseExpr(ctx.Input)
+	if err != nil {
+		return nil, err
+	}
+	aexpr, errs := CheckExpr(ctx, expr, env)
+	if errs != nil {
+		return nil, CheckErrors(errs)
+	}
+	if aexpr.IsConst() && len(aexpr.KnownType()) == 1 {
+		if ct, ok := aexpr.KnownType()[0].(ConstType); ok && ct != ConstNil {
+			v, errs := promoteConstToTyped(ctx, ct, constValue(aexpr.Const()), ct.DefaultPromotion(), aexpr)
+			if errs != nil {
+				return nil, CheckErrors(errs)
+			}
+			return Results{reflect.Value(v)}, nil
+		}
+	}
+	results, _, err := EvalExpr(ctx, aexpr, env)
+	if err != nil || results == nil {
+		return nil, err
+	}
+	return Results(*results), nil
+}
+
+// EvalString evaluates src in env and formats the results as they would
+// be entered, separated by commas.
+func EvalString(src string, env *Env) (string, error) {
+	results, err := Eval(src, env)
+	if err != nil {
+		return "", err
+	}
+	strs := make([]string, len(results))
+	for i, result := range results {
+		strs[i] = Inspect(result)
+	}
+	return strings.Join(strs, ", "), nil
+}
+
+// EvalAs evaluates src in env as a value of type T. src is checked as if
+// it were assigned to a variable of type T, so untyped constants are
+// converted to T and other values must be assignable to T.
+func EvalAs[T any](src string, env *Env) (T, error) {
+	var result T
+	t := reflect.TypeOf(&result).Elem()
+	ctx := &Ctx{Input: src}
+	expr, err := parser.ParseExpr(src)
+	if err != nil {
+		return result, err
+	}
+	aexpr, ok, errs := checkExprAssignableTo(ctx, expr, t, env)
+	if errs != nil {
+		return result, CheckErrors(errs)
+	} else if !ok {
+		return result, CheckErrors{ErrBadResultType{at(ctx, aexpr), t}}
+	}
+	xs, err := evalTypedExpr(ctx, aexpr, knownType{t}, env)
+	if err != nil {
+		return result, err
+	}
+	if x := xs[0]; x.IsValid() {
+		reflect.ValueOf(&result).Elem().Set(x)
+	}
+	return result, nil
+}
+
+// One returns the single result, or an error if there are none or many.
+func (r Results) One() (reflect.Value, error) {
+	if len(r) != 1 {
+		return reflect.Value{}, fmt.Errorf("eval: expected 1 result, got %d", len(r))
+	}
+	return r[0], nil
+}
+
+// Interface returns the single result as an interface{}
+func (r Results) Interface() (interface{}, error) {
+	v, err := r.One()
+	if err != nil || !v.IsValid() {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+// Int returns the single result, which must be a signed integer
+func (r Results) Int() (int64, error) {
+	v, err := r.oneOfKind("a signed integer", reflect.Int, reflect.Int8,
+		reflect.Int16, reflect.Int32, reflect.Int64)
+	if err != nil {
+		return 0, err
+	}
+	return v.Int(), nil
+}
+
+// Uint returns the single result, which must be an unsigned integer
+func (r Results) Uint() (uint64, error) {
+	v, err := r.oneOfKind("an unsigned integer", reflect.Uint, reflect.Uint8,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr)
+	if err != nil {
+		return 0, err
+	}
+	return v.Uint(), nil
+}
+
+// Float returns the single result, which must be a float
+func (r Results) Float() (float64, error) {
+	v, err := r.oneOfKind("a float", reflect.Float32, reflect.Float64)
+	if err != nil {
+		return 0, err
+	}
+	return v.Float(), nil
+}
+
+// Bool returns the single result, which must be a bool
+func (r Results) Bool() (bool, error) {
+	v, err := r.oneOfKind("a bool", reflect.Bool)
+	if err != nil {
+		return false, err
+	}
+	return v.Bool(), nil
+}
+
+// Str returns the single result, which must be a string. It is not named
+// String, so that Results is not mistaken for a fmt.Stringer.
+func (r Results) Str() (string, error) {
+	v, err := r.oneOfKind("a string", reflect.String)
+	if err != nil {
+		return "", err
+	}
+	return v.String(), nil
+}
+
+func (r Results) oneOfKind(what string, kinds ...reflect.Kind) (reflect.Value, error) {
+	v, err := r.One()
+	if err != nil {
+		return v, err
+	}
+	if v.IsValid() {
+		for _, kind := range kinds {
+			if v.Kind() == kind {
+				return v, nil
+			}
+		}
+		return v, fmt.Errorf("eval: result of type %v is not %s", v.Type(), what)
+	}
+	return v, fmt.Errorf("eval: result nil is not %s", what)
+}
@@ -0,0 +1,110 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	env := NewEnv()
+	x := 3
+	env.SetVar("x", &x)
+	env.SetFunc("divmod", func(a, b int) (int, int) { return a / b, a % b })
+
+	if r, err := Eval("x * 2", env); err != nil {
+		t.Fatal(err)
+	} else if i, err := r.Int(); err != nil || i != 6 {
+		t.Fatalf("Int() = %v, %v, expected 6", i, err)
+	}
+	if r, err := Eval("1 + 3", env); err != nil {
+		t.Fatal(err)
+	} else if v, err := r.Interface(); err != nil || v != 4 {
+		t.Fatalf("Interface() = %#v, %v, expected int 4", v, err)
+	}
+	if r, err := Eval("1 << 2", env); err != nil {
+		t.Fatal(err)
+	} else if v, err := r.Interface(); err != nil || v != 4 {
+		t.Fatalf("Interface() = %#v, %v, expected int 4", v, err)
+	}
+	if r, err := Eval("'a'", env); err != nil {
+		t.Fatal(err)
+	} else if v, err := r.Interface(); err != nil || v != 'a' {
+		t.Fatalf("Interface() = %#v, %v, expected rune 'a'", v, err)
+	}
+	if r, err := Eval(`"a" + "b"`, env); err != nil {
+		t.Fatal(err)
+	} else if s, err := r.Str(); err != nil || s != "ab" {
+		t.Fatalf("String() = %v, %v, expected ab", s, err)
+	}
+	if r, err := Eval("x > 2", env); err != nil {
+		t.Fatal(err)
+	} else if b, err := r.Bool(); err != nil || !b {
+		t.Fatalf("Bool() = %v, %v, expected true", b, err)
+	} else if _, err := r.Float(); err == nil || err.Error() != "eval: result of type bool is not a float" {
+		t.Fatalf("Float() of bool gave error %v", err)
+	}
+	if r, err := Eval("divmod(7, x)", env); err != nil {
+		t.Fatal(err)
+	} else if len(r) != 2 || r[0].Interface() != 2 || r[1].Interface() != 1 {
+		t.Fatalf("divmod(7, x) = %v, expected 2, 1", r)
+	} else if _, err := r.One(); err == nil || err.Error() != "eval: expected 1 result, got 2" {
+		t.Fatalf("One() of two results gave error %v", err)
+	}
+	if s, err := EvalString("divmod(7, x)", env); err != nil || s != "2, 1" {
+		t.Fatalf("EvalString() = %v, %v, expected 2, 1", s, err)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	env := NewEnv()
+	_, err := Eval("y + z", env)
+	var errs CheckErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("Expected two check errors, got %v", err)
+	} else if err.Error() != "undefined: y\nundefined: z" {
+		t.Fatalf("Wrong check errors %q", err.Error())
+	}
+	var undefined ErrUndefined
+	if !errors.As(err, &undefined) {
+		t.Fatalf("Expected CheckErrors to unwrap to ErrUndefined")
+	}
+
+	if _, err := Eval("1267650600228229401496703205376", env); err == nil || err.Error() != "constant 1267650600228229401496703205376 overflows int" {
+		t.Fatalf("Wrong error for overflowing constant %v", err)
+	}
+	if _, err := Eval("[]int{}[1]", env); err == nil || err.Error() != (PanicIndexOutOfBounds{}).Error() {
+		t.Fatalf("Wrong error for runtime panic %v", err)
+	}
+	if _, err := Eval("1 +", env); err == nil {
+		t.Fatalf("Expected parse error")
+	}
+}
+
+func TestEvalAs(t *testing.T) {
+	env := NewEnv()
+	x := 3
+	env.SetVar("x", &x)
+
+	if f, err := EvalAs[float64]("1", env); err != nil || f != 1 {
+		t.Fatalf("EvalAs[float64](1) = %v, %v", f, err)
+	}
+	if i, err := EvalAs[int]("x + 1", env); err != nil || i != 4 {
+		t.Fatalf("EvalAs[int](x + 1) = %v, %v", i, err)
+	}
+	if s, err := EvalAs[fmt.Stringer]("x", env); err == nil {
+		t.Fatalf("EvalAs[fmt.Stringer](x) expected error, got %v", s)
+	}
+	if v, err := EvalAs[interface{}]("x", env); err != nil || v != 3 {
+		t.Fatalf("EvalAs[interface{}](x) = %v, %v", v, err)
+	}
+	if p, err := EvalAs[*int]("nil", env); err != nil || p != nil {
+		t.Fatalf("EvalAs[*int](nil) = %v, %v", p, err)
+	}
+	if _, err := EvalAs[string]("x", env); err == nil || err.Error() != "cannot use x (type int) as type string in result" {
+		t.Fatalf("Wrong error for mismatched type %v", err)
+	}
+	if _, err := EvalAs[int8]("300", env); err == nil || err.Error() != "constant 300 overflows int8" {
+		t.Fatalf("Wrong error for overflowing constant %v", err)
+	}
+}
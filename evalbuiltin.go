@@ -1,6 +1,8 @@
 package eval
 
 import (
+	"io"
+	"os"
 	"reflect"
 )
 
@@ -29,6 +31,20 @@ func evalCallBuiltinExpr(ctx *Ctx, call *CallExpr, env *Env) ([]reflect.Value, e
 		return evalBuiltinDeleteExpr(ctx, call, env)
 	case "panic":
 		return evalBuiltinPanicExpr(ctx, call, env)
+	case "min":
+		return evalBuiltinMinMaxExpr(ctx, call, env, true)
+	case "max":
+		return evalBuiltinMinMaxExpr(ctx, call, env, false)
+	case "clear":
+		return evalBuiltinClearExpr(ctx, call, env)
+	case "close":
+		return evalBuiltinCloseExpr(ctx, call, env)
+	case "print":
+		return evalBuiltinPrintExpr(ctx, call, env, false)
+	case "println":
+		return evalBuiltinPrintExpr(ctx, call, env, true)
+	case "recover":
+		return evalBuiltinRecoverExpr(ctx, call, env)
 	default:
 		panic("eval: unimplemented builtin " + ident.Name)
 	}
@@ -98,17 +114,7 @@ func evalBuiltinMakeExpr(ctx *Ctx, call *CallExpr, env *Env) ([]reflect.Value, e
 			return nil, err
 		}
 	}
-	var res reflect.Value
-	switch resT.Kind() {
-	case reflect.Slice:
-		res = reflect.MakeSlice(resT, length, capacity)
-	case reflect.Map:
-		res = reflect.MakeMap(resT)
-	case reflect.Chan:
-		res = reflect.MakeChan(resT, length)
-	default:
-		panic(dytc("make(bad type)"))
-	}
+	res := builtinMake(resT, length, capacity)
 	return []reflect.Value{res}, nil
 }
 
@@ -193,3 +199,134 @@ func evalBuiltinPanicExpr(ctx *Ctx, call *CallExpr, env *Env) ([]reflect.Value,
 	}
 }
 
+
+func evalBuiltinMinMaxExpr(ctx *Ctx, call *CallExpr, env *Env, isMin bool) ([]reflect.Value, error) {
+	resT := call.KnownType()
+	xs := make([]reflect.Value, len(call.Args))
+	for i := range call.Args {
+		if x, err := evalTypedExpr(ctx, call.Args[i].(Expr), resT, env); err != nil {
+			return nil, err
+		} else {
+			xs[i] = x[0]
+		}
+	}
+	return []reflect.Value{builtinMinMax(isMin, xs)}, nil
+}
+
+func evalBuiltinClearExpr(ctx *Ctx, call *CallExpr, env *Env) ([]reflect.Value, error) {
+	if x, _, err := EvalExpr(ctx, call.Args[0].(Expr), env); err != nil {
+		return nil, err
+	} else {
+		builtinClear((*x)[0])
+		return []reflect.Value{}, nil
+	}
+}
+
+func evalBuiltinCloseExpr(ctx *Ctx, call *CallExpr, env *Env) ([]reflect.Value, error) {
+	if x, _, err := EvalExpr(ctx, call.Args[0].(Expr), env); err != nil {
+		return nil, err
+	} else {
+		return []reflect.Value{}, builtinClose((*x)[0])
+	}
+}
+
+func evalBuiltinPrintExpr(ctx *Ctx, call *CallExpr, env *Env, ln bool) ([]reflect.Value, error) {
+	xs, err := evalPrintArgs(ctx, call, env)
+	if err != nil {
+		return nil, err
+	}
+	builtinPrint(ctxStderr(ctx), ln, xs)
+	return []reflect.Value{}, nil
+}
+
+// Evaluate the arguments of print or println, untyped consts taking their
+// default type
+func evalPrintArgs(ctx *Ctx, call *CallExpr, env *Env) ([]reflect.Value, error) {
+	xs := make([]reflect.Value, len(call.Args))
+	for i := range call.Args {
+		arg := call.Args[i].(Expr)
+		t := arg.KnownType()[0]
+		if ct, ok := t.(ConstType); ok {
+			t = ct.DefaultPromotion()
+		}
+		if x, err := evalTypedExpr(ctx, arg, knownType{t}, env); err != nil {
+			return nil, err
+		} else {
+			xs[i] = x[0]
+		}
+	}
+	return xs, nil
+}
+
+// The destination of print and println
+func ctxStderr(ctx *Ctx) io.Writer {
+	if ctx.Stderr == nil {
+		return os.Stderr
+	}
+	return ctx.Stderr
+}
+
+func evalBuiltinRecoverExpr(ctx *Ctx, call *CallExpr, env *Env) ([]reflect.Value, error) {
+	// Outside of an interpreted function, recover is never called by a
+	// deferred call, so as in Go, it returns nil.
+	if f := env.callFrame(); f != nil {
+		return []reflect.Value{f.recover()}, nil
+	}
+	return []reflect.Value{reflect.Zero(emptyInterface)}, nil
+}
+
+// Evaluate the arguments of a builtin call for a defer or go statement.
+// Only builtins allowed in statement context are accepted by the checker.
+func prepareBuiltinCall(ctx *Ctx, call *CallExpr, env *Env) (deferredCall, error) {
+	name := call.Fun.(*Ident).Name
+	if name == "recover" {
+		// recover is not called directly by a deferred function, so as
+		// in Go, it does nothing.
+		return func(*frame, *panicState) error { return nil }, nil
+	} else if name == "print" || name == "println" {
+		xs, err := evalPrintArgs(ctx, call, env)
+		if err != nil {
+			return nil, err
+		}
+		for i := range xs {
+			xs[i] = detach(xs[i])
+		}
+		return func(*frame, *panicState) error {
+			builtinPrint(ctxStderr(ctx), name == "println", xs)
+			return nil
+		}, nil
+	} else if name == "panic" {
+		xs, err := evalTypedExpr(ctx, call.Args[0].(Expr), knownType{emptyInterface}, env)
+		if err != nil {
+			return nil, err
+		}
+		return func(*frame, *panicState) error { return builtinPanic(xs[0]) }, nil
+	}
+
+	x, _, err := EvalExpr(ctx, call.Args[0].(Expr), env)
+	if err != nil {
+		return nil, err
+	}
+	arg0 := detach((*x)[0])
+	switch name {
+	case "close":
+		return func(*frame, *panicState) error { return builtinClose(arg0) }, nil
+	case "clear":
+		return func(*frame, *panicState) error { builtinClear(arg0); return nil }, nil
+	case "delete":
+		k, err := evalTypedExpr(ctx, call.Args[1].(Expr), knownType{arg0.Type().Key()}, env)
+		if err != nil {
+			return nil, err
+		}
+		return func(*frame, *panicState) error { builtinDelete(arg0, k[0]); return nil }, nil
+	case "copy":
+		ys, _, err := EvalExpr(ctx, call.Args[1].(Expr), env)
+		if err != nil {
+			return nil, err
+		}
+		y := detach((*ys)[0])
+		return func(*frame, *panicState) error { builtinCopy(arg0, y); return nil }, nil
+	default:
+		panic(dytc("deferred builtin " + name))
+	}
+}
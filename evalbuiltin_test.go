@@ -2,7 +2,10 @@ package eval
 
 
 import (
+	"bytes"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -101,3 +104,121 @@ func TestBuiltinDelete(t *testing.T) {
 		t.Fatalf("Failed to delete(a, 1)`")
 	}
 }
+
+func TestBuiltinMinMax(t *testing.T) {
+	env := makeEnv()
+	i, f := 3, 2.5
+	env.Vars["i"] = reflect.ValueOf(&i)
+	env.Vars["f"] = reflect.ValueOf(&f)
+
+	expectResult(t, "min(i, 1, 5)", env, 1)
+	expectResult(t, "max(i, 1, 5)", env, 5)
+	expectResult(t, "min(f)", env, 2.5)
+	expectResult(t, "max(f, 1, 7.5)", env, 7.5)
+	expectResult(t, `min("b", "a", "c")`, env, "a")
+	expectResult(t, "min(int8(3), -4)", env, int8(-4))
+	expectConst(t, "min(1, 2.5, 'a')", env, NewConstFloat64(1), ConstFloat)
+	expectConst(t, "max(1, 2.5, 'a')", env, NewConstFloat64(97), ConstFloat)
+	expectConst(t, "max(int8(1), 2)", env, int8(2), i8)
+}
+
+func TestBuiltinMinMaxFloats(t *testing.T) {
+	env := makeEnv()
+	zero, nan := 0.0, math.NaN()
+	env.Vars["zero"] = reflect.ValueOf(&zero)
+	env.Vars["nan"] = reflect.ValueOf(&nan)
+
+	if r := (*getResults(t, "min(1, nan, -1)", env))[0].Float(); !math.IsNaN(r) {
+		t.Fatalf("min with NaN should be NaN, not %v", r)
+	}
+	if r := (*getResults(t, "min(zero, -zero)", env))[0].Float(); !math.Signbit(r) {
+		t.Fatalf("min(0, -0) should be -0")
+	}
+}
+
+func TestBuiltinClear(t *testing.T) {
+	env := makeEnv()
+	s := []int{1, 2}
+	m := map[string]int{"a": 1}
+	env.Vars["s"] = reflect.ValueOf(&s)
+	env.Vars["m"] = reflect.ValueOf(&m)
+
+	expectResults(t, "clear(s)", env, &[]interface{}{})
+	expectResults(t, "clear(m)", env, &[]interface{}{})
+	if !reflect.DeepEqual(s, []int{0, 0}) || len(m) != 0 {
+		t.Fatalf("Expected s and m to be cleared, got %v and %v", s, m)
+	}
+}
+
+func TestBuiltinClose(t *testing.T) {
+	env := makeEnv()
+	c := make(chan int)
+	var n chan int
+	env.Vars["c"] = reflect.ValueOf(&c)
+	env.Vars["n"] = reflect.ValueOf(&n)
+
+	expectResults(t, "close(c)", env, &[]interface{}{})
+	if _, ok := <-c; ok {
+		t.Fatalf("Expected c to be closed")
+	}
+	expectPanic(t, "close(c)", env, "close of closed channel")
+	expectPanic(t, "close(n)", env, "close of nil channel")
+}
+
+func TestBuiltinPrint(t *testing.T) {
+	env := makeEnv()
+	var out bytes.Buffer
+	var p *int
+
+	env.Vars["p"] = reflect.ValueOf(&p)
+	expr := `println(1, "a", true, 1.5, 2i, p, 'a')`
+	ctx := &Ctx{Input: expr, Stderr: &out}
+	expectResultsCtx(t, ctx, env, &[]interface{}{})
+	expected := "1 a true +1.500000e+000 (+0.000000e+000+2.000000e+000i) 0x0 97\n"
+	if out.String() != expected {
+		t.Fatalf("println wrote `%s`, expected `%s`", out.String(), expected)
+	}
+
+	out.Reset()
+	ctx.Input = `print(1, "a")`
+	expectResultsCtx(t, ctx, env, &[]interface{}{})
+	if out.String() != "1a" {
+		t.Fatalf("print wrote `%s`, expected `1a`", out.String())
+	}
+
+	// Interfaces print as their two words, not their dynamic values
+	var i, e interface{}
+	i = p
+	env.Vars["i"] = reflect.ValueOf(&i)
+	env.Vars["e"] = reflect.ValueOf(&e)
+	out.Reset()
+	ctx.Input = `println(e, i)`
+	expectResultsCtx(t, ctx, env, &[]interface{}{})
+	words := strings.Fields(out.String())
+	if len(words) != 2 || words[0] != "(0x0,0x0)" || !strings.HasSuffix(words[1], ",0x0)") || words[1] == words[0] {
+		t.Fatalf("println of interfaces wrote `%s`", out.String())
+	}
+}
+
+func TestBuiltinRecover(t *testing.T) {
+	env := makeEnv()
+	expectResult(t, "recover()", env, nil)
+}
+
+func TestCheckBuiltinNewBuiltins(t *testing.T) {
+	env := makeEnv()
+	var r <-chan int
+	env.Vars["r"] = reflect.ValueOf(&r)
+
+	expectCheckError(t, "min()", env, "missing argument to min: min()")
+	expectCheckError(t, "min(1, int8(1), int16(2))", env,
+		"invalid operation: min(1, int8(1), int16(2)) (mismatched types int8 and int16)")
+	expectCheckError(t, `max(1, "a")`, env,
+		`invalid operation: max(1, "a") (mismatched types untyped number and untyped string)`)
+	expectCheckError(t, "min(true)", env, "invalid argument true (type bool) for min")
+	expectCheckError(t, "min(1i)", env, "invalid argument 1i (type complex128) for min")
+	expectCheckError(t, "clear(1)", env, "invalid argument 1 (type int) for clear")
+	expectCheckError(t, "close(nil)", env, "use of untyped nil")
+	expectCheckError(t, "close(r)", env, "invalid operation: close(r) (cannot close receive-only channel)")
+	expectCheckError(t, "recover(1)", env, "too many arguments to recover: recover(1)")
+}
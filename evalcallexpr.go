@@ -23,11 +23,11 @@ func evalCallTypeExpr(ctx *Ctx, call *CallExpr, env *Env) ([]reflect.Value, erro
 	t := arg.KnownType()[0]
 	if t == ConstNil {
 		// This has already been typechecked to be a nil-able type
-		return []reflect.Value{hackedNew(call.KnownType()[0]).Elem()}, nil
+		return []reflect.Value{reflect.New(call.KnownType()[0]).Elem()}, nil
 	} else if v, _, err := EvalExpr(ctx, arg, env); err != nil {
-		return nil, nil
+		return nil, err
 	} else {
-		cast := (*v)[0].Convert(unhackType(call.KnownType()[0]))
+		cast := (*v)[0].Convert(call.KnownType()[0])
 		return []reflect.Value{cast}, nil
 	}
 }
@@ -39,10 +39,25 @@ func evalCallFunExpr(ctx *Ctx, call *CallExpr, env *Env) ([]reflect.Value, error
 	}
 
 	fun := (*v)[0]
-	ft := fun.Type()
-	numIn := ft.NumIn()
+	args, err := evalCallArgs(ctx, call, fun.Type(), env)
+	if err != nil {
+		return nil, err
+	} else if fun.IsNil() {
+		return nil, PanicInvalidDereference{}
+	}
+	if fn, ok := lookupInterpFunc(fun); ok {
+		if !call.argNEllipsis {
+			args = packVariadic(fun.Type(), args)
+		}
+		return fn.invoke(ctx, args, env.callFrame(), nil)
+	}
+	return callFunc(ctx, fun, args, call.argNEllipsis, env.callFrame())
+}
 
-	// Evaluate arguments
+// Evaluate the arguments of call to a func of type ft, in the form taken
+// by reflect.Value.Call, or CallSlice if call.argNEllipsis.
+func evalCallArgs(ctx *Ctx, call *CallExpr, ft reflect.Type, env *Env) ([]reflect.Value, error) {
+	numIn := ft.NumIn()
 	args := make([]reflect.Value, len(call.Args))
 	if call.arg0MultiValued {
 		if argp, _, err := EvalExpr(ctx, call.Args[0].(Expr), env); err != nil {
@@ -75,9 +90,42 @@ func evalCallFunExpr(ctx *Ctx, call *CallExpr, env *Env) ([]reflect.Value, error
 			}
 		}
 	}
+	return args, nil
+}
+
+// Pack the trailing args of a call to a variadic func of type ft into a
+// slice, as CallSlice expects.
+func packVariadic(ft reflect.Type, args []reflect.Value) []reflect.Value {
+	if !ft.IsVariadic() {
+		return args
+	}
+	n := ft.NumIn() - 1
+	tail := reflect.MakeSlice(ft.In(n), len(args)-n, len(args)-n)
+	for i, arg := range args[n:] {
+		tail.Index(i).Set(arg)
+	}
+	return append(args[:n:n], tail)
+}
 
-	var out []reflect.Value
-	if call.argNEllipsis {
+// Call fun, returning the error of a failed interpreted function rather
+// than panicking. If caller is not nil, the call is made by that
+// interpreted function, and other panics are returned as a PanicHost for it
+// to unwind with. Interpreted functions which fun calls back continue the
+// call chain of caller, under the options of ctx.
+func callFunc(ctx *Ctx, fun reflect.Value, args []reflect.Value, ellipsis bool, caller *frame) (out []reflect.Value, err error) {
+	defer enterHostCall(ctx, caller)()
+	defer func() {
+		if r := recover(); r != nil {
+			if p, ok := r.(funcPanic); ok {
+				err = p.err
+			} else if caller != nil {
+				err = PanicHost{r}
+			} else {
+				panic(r)
+			}
+		}
+	}()
+	if ellipsis {
 		out = fun.CallSlice(args)
 	} else {
 		out = fun.Call(args)
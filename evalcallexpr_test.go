@@ -55,3 +55,19 @@ func TestTypeConversionWithEmptyInterface(t *testing.T) {
 	expectResult(t, "interface{}('a')", env, interface{}('a'))
 }
 
+func TestTypeConversionByteRuneAliases(t *testing.T) {
+	env := makeEnv()
+	b := []uint8("a")
+	r := rune('x')
+	env.Vars["b"] = reflect.ValueOf(&b)
+	env.Vars["r"] = reflect.ValueOf(&r)
+
+	expectType(t, "[]byte(string(b))", env, reflect.TypeOf([]uint8{}))
+	expectType(t, "rune(r)", env, reflect.TypeOf(int32(0)))
+	expectType(t, "map[byte]rune{}", env, reflect.TypeOf(map[uint8]int32{}))
+	expectResult(t, "append([]byte(\"x\"), b...)", env, []byte("xa"))
+	expectResult(t, "int32(r) + rune(1)", env, int32('y'))
+	expectCheckError(t, "rune(1) + int8(1)", env,
+		"invalid operation: rune(1) + 1 (mismatched types rune and int8)")
+	expectCheckError(t, "byte(256)", env, "constant 256 overflows byte")
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"unsafe"
 )
 
 func evalCompositeLit(ctx *Ctx, lit *CompositeLit, env *Env) (reflect.Value, error) {
@@ -86,6 +87,15 @@ func evalCompositeLitStruct(ctx *Ctx, t reflect.Type, lit *CompositeLit, env *En
 			elt = lit.Elts[i].(Expr)
 		}
 		field := v.Field(f)
+		if !field.CanSet() {
+			// Unexported fields of a struct type declared by
+			// interpreted code, or of a host type of the package of
+			// env. checkCompositeLitStruct rejects all others.
+			if !ownsField(t, t.Field(f), env) {
+				panic(dytc("unexported field of " + t.String()))
+			}
+			field = reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+		}
 		if elem, err := evalTypedExpr(ctx, elt, knownType{field.Type()}, env); err != nil {
 			return reflect.Value{}, err
 		} else {
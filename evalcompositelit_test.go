@@ -116,3 +116,26 @@ func TestCompositeStructKeyValues(t *testing.T) {
 
 	expectResult(t, expr, env, expected)
 }
+
+func TestCompositeStructUnexported(t *testing.T) {
+	env := NewEnv()
+	env.Types["SelPrivate"] = reflect.TypeOf(SelPrivate{})
+	env.Types["SelNested"] = reflect.TypeOf(SelNested{})
+	expectCheckError(t, "SelPrivate{a: 1}", env, "unknown eval.SelPrivate field 'a' in struct literal")
+	expectCheckError(t, "SelPrivate{1, SelNested{}, nil}", env,
+		"implicit assignment of unexported field 'a' in eval.SelPrivate literal",
+		"implicit assignment of unexported field 'n' in eval.SelPrivate literal",
+		"implicit assignment of unexported field 's' in eval.SelPrivate literal")
+	expectResult(t, "SelPrivate{}", env, SelPrivate{})
+
+	// Types declared by EvalDecls belong to the package of env
+	if err := EvalDecls("type T struct{ a int }; var x = T{a: 2}", env); err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, "x == T{2}", env, true)
+
+	// As do host types of that package
+	env = makeEnv()
+	env.Types["SelPrivate"] = reflect.TypeOf(SelPrivate{})
+	expectResult(t, "SelPrivate{a: 1}", env, SelPrivate{a: 1})
+}
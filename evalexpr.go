@@ -25,6 +25,8 @@ func EvalExpr(ctx *Ctx, expr Expr, env *Env) (*[]reflect.Value, bool, error) {
 		v, err := evalBasicLit(ctx, node)
 		return &[]reflect.Value{v}, true, err
 	case *FuncLit:
+		v, err := evalFuncLit(ctx, node, env)
+		return &[]reflect.Value{v}, true, err
 	case *CompositeLit:
 		v, err := evalCompositeLit(ctx, node, env)
 		return &[]reflect.Value{v}, true, err
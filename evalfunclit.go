@@ -0,0 +1,29 @@
+package eval
+
+import (
+	"reflect"
+)
+
+// Evaluate a func literal to a closure over env. A closure made in the body
+// of an interpreted function is known to interpFuncs until that call
+// returns, so that interpreted code may call it directly, as when it is
+// deferred and recovers a panic. After that it remains callable through
+// reflect, as is one made outside of any function.
+func evalFuncLit(ctx *Ctx, lit *FuncLit, env *Env) (reflect.Value, error) {
+	fn := &interpFunc{
+		ctx:     ctx,
+		name:    "func literal",
+		t:       lit.KnownType()[0],
+		env:     env,
+		params:  lit.params,
+		results: lit.results,
+		body:    lit.Body.List,
+		defined: true,
+	}
+	f := reflect.MakeFunc(fn.t, fn.call)
+	if frame := env.callFrame(); frame != nil {
+		interpFuncs.Store(funcKey(f), fn)
+		frame.closures = append(frame.closures, f)
+	}
+	return f, nil
+}
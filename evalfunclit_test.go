@@ -0,0 +1,65 @@
+package eval
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestEvalFuncLit(t *testing.T) {
+	env := NewEnv()
+	env.SetFunc("sortInts", func(s []int, less func(a, b int) bool) {
+		sort.Slice(s, func(i, j int) bool { return less(s[i], s[j]) })
+	})
+	err := EvalDecls(`func counter() func() int {
+	n := 0
+	return func() int {
+		n++
+		return n
+	}
+}
+func count(k int) int {
+	next := counter()
+	for i := 1; i < k; i++ {
+		next()
+	}
+	return next()
+}
+func captures() (s []int) {
+	var fs []func() int
+	for i := 0; i < 3; i++ {
+		fs = append(fs, func() int { return i })
+	}
+	for _, f := range fs {
+		s = append(s, f())
+	}
+	return s
+}
+func descending(s []int) []int {
+	sortInts(s, func(a, b int) bool { return a > b })
+	return s
+}
+func fib(n int) int {
+	var f func(int) int
+	f = func(n int) int {
+		if n < 2 {
+			return n
+		}
+		return f(n-1) + f(n-2)
+	}
+	return f(n)
+}`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, "count(3)", env, 3)
+	expectResult(t, "captures()", env, []int{0, 1, 2})
+	expectResult(t, "descending([]int{2, 3, 1})", env, []int{3, 2, 1})
+	expectResult(t, "fib(10)", env, 55)
+	expectResult(t, "func(x int) int { return x * 2 }(3)", env, 6)
+	expectResult(t, "counter()()", env, 1)
+
+	expectCheckError(t, "func() int { }", env, "missing return at end of function")
+	expectCheckError(t, "func() { x }", env, "undefined: x")
+	expectCheckErrorCtx(t, &Ctx{Input: "func() {}", ReadOnly: true}, env,
+		"func literal not allowed in read-only mode: func() {}")
+}
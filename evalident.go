@@ -10,11 +10,28 @@ func evalIdent(ctx *Ctx, ident *Ident, env *Env) (reflect.Value, error) {
 	}
 
 	name := ident.Name
+	env = env.ancestor(ident.depth)
 	switch ident.source {
 	case envVar:
-		return env.Vars[name].Elem(), nil
+		envMu.RLock()
+		defer envMu.RUnlock()
+		// The body of a declared func may outlive a binding it was
+		// checked against, such as one undone by a failed declaration
+		if v, ok := env.Vars[name]; ok {
+			return v.Elem(), nil
+		}
+		return reflect.Value{}, ErrUndefined{at(ctx, ident)}
 	case envFunc:
-		return env.Funcs[name], nil
+		envMu.RLock()
+		defer envMu.RUnlock()
+		if f, ok := env.Funcs[name]; ok {
+			return f, nil
+		}
+		return reflect.Value{}, ErrUndefined{at(ctx, ident)}
+	case envField:
+		return fieldByIndex(ctx, env.receiver, ident.field, env)
+	case envMethod:
+		return env.receiver.Method(ident.method.Index), nil
 	default:
                 panic(dytc("missing identifier"))
 	}
@@ -14,16 +14,10 @@ func evalIndexExpr(ctx *Ctx, index *IndexExpr, env *Env) ([]reflect.Value, error
 	t := index.X.(Expr).KnownType()[0]
 	switch t.Kind() {
 	case reflect.Map:
-		k, err := evalTypedExpr(ctx, index.Index.(Expr), knownType{t.Key()}, env)
+		v, _, err := evalMapIndex(ctx, x, index, env)
 		if err != nil {
 			return []reflect.Value{}, err
 		}
-		v := x.MapIndex(k[0])
-		ok := v.IsValid()
-		if !ok {
-			v = reflect.New(t.Key()).Elem()
-		}
-		// TODO[crc] return ok as well when assignment support is added
 		return []reflect.Value{v}, nil
 	case reflect.Ptr:
 		// Short hand for array pointers
@@ -40,3 +34,18 @@ func evalIndexExpr(ctx *Ctx, index *IndexExpr, env *Env) ([]reflect.Value, error
 		return []reflect.Value{x.Index(i)}, nil
 	}
 }
+
+// Index the map x, giving the zero value and false if the key is missing
+func evalMapIndex(ctx *Ctx, x reflect.Value, index *IndexExpr, env *Env) (reflect.Value, bool, error) {
+	t := x.Type()
+	k, err := evalTypedExpr(ctx, index.Index.(Expr), knownType{t.Key()}, env)
+	if err != nil {
+		return reflect.Value{}, false, err
+	}
+	v := x.MapIndex(k[0])
+	ok := v.IsValid()
+	if !ok {
+		v = reflect.New(t.Elem()).Elem()
+	}
+	return v, ok, nil
+}
@@ -2,12 +2,15 @@ package eval
 
 import (
 	"reflect"
+	"unsafe"
 )
 
 func evalSelectorExpr(ctx *Ctx, selector *SelectorExpr, env *Env) (reflect.Value, error) {
 
-	if selector.pkgName != "" {
-		vs, _, err := evalIdentExprCallback(ctx, selector.Sel, env.Pkgs[selector.pkgName])
+	if selector.methodExpr.IsValid() {
+		return selector.methodExpr, nil
+	} else if selector.pkgName != "" {
+		vs, _, err := evalIdentExprCallback(ctx, selector.Sel, pkgOf(selector, env))
 		return *vs, err
 	}
 
@@ -18,18 +21,93 @@ func evalSelectorExpr(ctx *Ctx, selector *SelectorExpr, env *Env) (reflect.Value
 	v := (*vs)[0]
 	t := v.Type()
 	if selector.field != nil {
-		if t.Kind() == reflect.Ptr {
-			v = v.Elem()
-		}
-		return v.FieldByIndex(selector.field), nil
+		return fieldByIndex(ctx, v, selector.field, env)
 	}
 
+	if ctx.Policy != nil && selector.promoted != nil {
+		// Check the method of the embedded interface actually dispatched to
+		iface, err := fieldByIndex(ctx, v, selector.promoted, env)
+		if err != nil {
+			return reflect.Value{}, err
+		} else if !iface.IsNil() {
+			callee := Callee{Name: selector.Sel.Name, Recv: iface.Elem().Type()}
+			if !ctx.Policy.Allow(callee) {
+				return reflect.Value{}, PanicCallDenied{callee}
+			}
+		}
+	}
 	if selector.isPtrReceiver {
 		v = v.Addr()
+	} else if ctx.Policy != nil && t.Kind() == reflect.Interface && !v.IsNil() {
+		// Check the method actually dispatched to
+		callee := Callee{Name: selector.Sel.Name, Recv: v.Elem().Type()}
+		if !ctx.Policy.Allow(callee) {
+			return reflect.Value{}, PanicCallDenied{callee}
+		}
 	}
 	return v.Method(selector.method), nil
 }
 
+// The package selected from by a package selector, as resolved when checked
+func pkgOf(selector *SelectorExpr, env *Env) *Env {
+	x := selector.X.(*Ident)
+	return env.ancestor(x.depth).Pkgs[selector.pkgName]
+}
+
+// Equivalent of v.FieldByIndex(index), but v may be a pointer to a struct
+// and nil pointers, including embedded ones, produce a PanicInvalidDereference.
+// Unexported fields which env owns are re-wrapped so that they may be used
+// like exported ones, as are all others if ctx.Unexported is set.
+func fieldByIndex(ctx *Ctx, v reflect.Value, index []int, env *Env) (reflect.Value, error) {
+	for n, i := range index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, PanicInvalidDereference{}
+			}
+			v = v.Elem()
+		}
+		field := v.Type().Field(i)
+		// An unexported embedded field along the way is reached by
+		// promotion, which Go allows too
+		promoted := field.Anonymous && n < len(index)-1
+		owned := !field.IsExported() && (promoted || ownsField(v.Type(), field, env))
+		if (owned || ctx.Unexported) && !v.CanAddr() && !field.IsExported() {
+			// Only addressable fields can be re-wrapped
+			tmp := reflect.New(v.Type()).Elem()
+			tmp.Set(v)
+			v = tmp
+		}
+		v = v.Field(i)
+		if owned && !v.CanInterface() {
+			v = reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+		} else if ctx.Unexported && !v.CanInterface() {
+			v = exposeUnexported(ctx, v)
+		}
+	}
+	return v, nil
+}
+
+// Can code in env use the unexported field of struct type t as Go would
+// allow, through unsafe. It must belong to a struct declared by EvalDecls,
+// or to the package of env.
+func ownsField(t reflect.Type, field reflect.StructField, env *Env) bool {
+	_, declared := declaredStructs.Load(t)
+	return declared || isFieldVisible(field, env)
+}
+
+// Re-wrap the addressable value v of an unexported field so that it may be
+// used like any other value. Unless ctx.UnexportedWritable is set, the
+// result is a copy, and so writes do not reach the original field.
+func exposeUnexported(ctx *Ctx, v reflect.Value) reflect.Value {
+	exposed := reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+	if ctx.UnexportedWritable {
+		return exposed
+	}
+	cp := reflect.New(v.Type()).Elem()
+	cp.Set(exposed)
+	return cp
+}
+
 // TODO[crc] Everything below here goes with the Env interface{} refactor
 func EvalSelectorExpr(ctx *Ctx, selector *SelectorExpr, env *Env) (*reflect.Value, bool, error) {
 	v, err := evalSelectorExpr(ctx, selector, env)
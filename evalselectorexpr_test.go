@@ -162,3 +162,142 @@ func TestSelectPackageFunc(t *testing.T) {
 	expectResult(t, `fmt.Sprintf("abc")`, env, fmt.Sprintf("abc"))
 }
 
+
+func TestSelectMethodExpr(t *testing.T) {
+	env := makeEnv()
+	env.Types["SelStruct"] = reflect.TypeOf(SelStruct{})
+	env.Types["SelInt"] = reflect.TypeOf(SelInt(0))
+	s := SelStruct{}
+	env.Vars["s"] = reflect.ValueOf(&s)
+	expectResult(t, "SelStruct.E(s)", env, SelStruct.E(s))
+	expectResult(t, "(*SelStruct).E(&s)", env, (*SelStruct).E(&s))
+	expectResult(t, "(*SelStruct).F(&s)", env, (*SelStruct).F(&s))
+	expectResult(t, "SelInt.E(2)", env, SelInt.E(2))
+}
+
+func TestSelectMethodExprInterface(t *testing.T) {
+	env := makeEnv()
+	env.Types["SelInterface"] = reflect.TypeOf(new(SelInterface)).Elem()
+	var i SelInterface = new(SelInt)
+	env.Vars["i"] = reflect.ValueOf(&i)
+	expectResult(t, "SelInterface.F(i)", env, SelInterface.F(i))
+}
+
+func TestSelectPackageMethodExpr(t *testing.T) {
+	env := makeEnv()
+	pkg := makeEnv()
+	pkg.Types["Stringer"] = reflect.TypeOf(new(fmt.Stringer)).Elem()
+	env.Pkgs["fmt"] = pkg
+	d := reflect.TypeOf(0)
+	env.Vars["d"] = reflect.ValueOf(&d)
+	expectResult(t, "fmt.Stringer.String(d)", env, d.String())
+}
+
+func TestSelectMethodExprErrors(t *testing.T) {
+	env := makeEnv()
+	env.Types["SelStruct"] = reflect.TypeOf(SelStruct{})
+	expectCheckError(t, "SelStruct.F", env,
+		"invalid method expression SelStruct.F (needs pointer receiver: (*eval.SelStruct).F)")
+	expectCheckError(t, "SelStruct.G", env,
+		"SelStruct.G undefined (type eval.SelStruct has no method G)")
+}
+
+type SelEmbedP struct {
+	*SelNested
+	P *SelStruct
+}
+
+func TestSelectNilEmbeddedPointer(t *testing.T) {
+	env := makeEnv()
+	s := SelEmbedP{}
+	env.Vars["s"] = reflect.ValueOf(&s)
+	expectPanic(t, "s.D", env, PanicInvalidDereference{}.Error())
+	expectPanic(t, "s.P.A", env, PanicInvalidDereference{}.Error())
+	expectPanic(t, "s.P.D", env, PanicInvalidDereference{}.Error())
+	expectResult(t, "s.SelNested == nil", env, true)
+
+	s.SelNested = &SelNested{D: 3}
+	expectResult(t, "s.D", env, 3)
+}
+
+func TestSelectNilStructPointer(t *testing.T) {
+	env := makeEnv()
+	var s *SelStruct
+	env.Vars["s"] = reflect.ValueOf(&s)
+	expectPanic(t, "s.A", env, PanicInvalidDereference{}.Error())
+	expectPanic(t, "s.B.C", env, PanicInvalidDereference{}.Error())
+}
+
+type SelPrivate struct {
+	a int
+	n SelNested
+	s fmt.Stringer
+}
+
+func TestSelectUnexportedField(t *testing.T) {
+	env := makeEnv()
+	p := SelPrivate{a: 4, n: SelNested{D: 5}, s: reflect.TypeOf(0)}
+	env.Vars["p"] = reflect.ValueOf(&p)
+	env.Funcs["f"] = reflect.ValueOf(func() SelPrivate { return p })
+
+	unexported := func(expr string) *Ctx {
+		return &Ctx{Input: expr, Unexported: true}
+	}
+	expectResultCtx(t, unexported("p.a + 1"), env, 5)
+	expectResultCtx(t, unexported("p.a == 4"), env, true)
+	expectResultCtx(t, unexported("p.n.D"), env, 5)
+	expectResultCtx(t, unexported("f().a"), env, 4)
+	expectResultCtx(t, unexported("p.s.String()"), env, "int")
+	if v := (*getResultsCtx(t, unexported("p.n"), env))[0]; Inspect(v) != "{D: 5,}" {
+		t.Fatalf("Inspect(p.n) = %s", Inspect(v))
+	}
+}
+
+func TestSelectUnexportedFieldWritable(t *testing.T) {
+	// Outside of this package, so that the fields are not its own
+	env := makeEnv()
+	env.Path = "main"
+	p := SelPrivate{a: 4}
+	env.Vars["p"] = reflect.ValueOf(&p)
+
+	ctx := &Ctx{Input: "p.a", Unexported: true}
+	(*getResultsCtx(t, ctx, env))[0].SetInt(6)
+	if p.a != 4 {
+		t.Fatalf("Copy of p.a should not alias the field")
+	}
+
+	ctx.UnexportedWritable = true
+	(*getResultsCtx(t, ctx, env))[0].SetInt(6)
+	if p.a != 6 {
+		t.Fatalf("Expected p.a to be set to 6, not %d", p.a)
+	}
+}
+
+type selHidden struct {
+	H int
+}
+
+type SelEmbedHidden struct {
+	selHidden
+}
+
+func TestSelectUnexportedForeignField(t *testing.T) {
+	// Outside of this package, as for user code
+	env := makeEnv()
+	env.Path = "main"
+	p := SelPrivate{a: 4}
+	e := SelEmbedHidden{selHidden{5}}
+	env.Vars["p"] = reflect.ValueOf(&p)
+	env.Vars["e"] = reflect.ValueOf(&e)
+
+	expectCheckError(t, "p.a", env, "p.a undefined (cannot refer to unexported field a)")
+	expectCheckError(t, "e.selHidden", env,
+		"e.selHidden undefined (cannot refer to unexported field selHidden)")
+
+	// Exported fields may be promoted through unexported ones
+	expectResult(t, "e.H + 1", env, 6)
+	if err := EvalDecls("var h = e.H", env); err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, "h", env, 5)
+}
@@ -11,6 +11,13 @@ func evalSliceExpr(ctx *Ctx, slice *SliceExpr, env *Env) (reflect.Value, error)
 		return reflect.Value{}, err
 	}
 	x := (*xs)[0]
+	if x.Kind() == reflect.Ptr {
+		// Short hand for array pointers
+		if x.IsNil() {
+			return reflect.Value{}, PanicInvalidDereference{}
+		}
+		x = x.Elem()
+	}
 
 	var l, h int
 	if slice.Low != nil {
@@ -26,12 +33,7 @@ func evalSliceExpr(ctx *Ctx, slice *SliceExpr, env *Env) (reflect.Value, error)
 		h = x.Len()
 	}
 
-	t := slice.KnownType()[0]
-	switch t.Kind() {
-	case reflect.Ptr:
-		// Short hand for array pointers
-		x = x.Elem()
-		fallthrough
+	switch x.Kind() {
 	case reflect.Array, reflect.String:
 		if l < 0 || h > x.Len() || h < l {
 			return reflect.Value{}, PanicSliceOutOfBounds{}
@@ -0,0 +1,699 @@
+package eval
+
+import (
+	"fmt"
+	"reflect"
+
+	"go/ast"
+	"go/token"
+)
+
+// State of an executing call of an interpreted function
+type frame struct {
+	// Number of interpreted calls in the chain ending at this one
+	depth int
+
+	// The goroutine running the call
+	g *goroutine
+
+	// Pointers to the results, set by return statements
+	results []reflect.Value
+
+	// Calls deferred by the function, in the order of their defer statements
+	deferred []deferredCall
+
+	// For a call deferred by a panicking frame, the panic recover stops
+	recoverable *panicState
+
+	// Closures made by the call, known to interpFuncs until it returns
+	closures []reflect.Value
+}
+
+// A panic unwinding a frame, or nil err if the frame returned normally
+type panicState struct {
+	err       error
+	recovered bool
+}
+
+// A call whose function and arguments have been evaluated by a defer or go
+// statement. caller is the frame making the call, and p the panic a
+// deferred interpreted function may recover.
+type deferredCall func(caller *frame, p *panicState) error
+
+// Run the deferred calls of f, last first, as the function exits with the
+// runtime error err, or nil. Returns the panic which remains, if any. As in
+// Go, a panic in a deferred call replaces the one before it.
+func (f *frame) runDeferred(err error) error {
+	p := &panicState{err: err}
+	for i := len(f.deferred) - 1; i >= 0; i -= 1 {
+		if err := f.deferred[i](f, p); err != nil {
+			p = &panicState{err: err}
+		}
+	}
+	if p.recovered {
+		return nil
+	}
+	return p.err
+}
+
+// The value of recover() in f. Only a call deferred by a panicking frame
+// may recover, and only once.
+func (f *frame) recover() reflect.Value {
+	r := reflect.New(emptyInterface).Elem()
+	p := f.recoverable
+	if p == nil || p.err == nil || p.recovered {
+		return r
+	}
+	p.recovered = true
+	if user, ok := p.err.(PanicUser); ok {
+		// The value given to panic
+		if v := reflect.Value(user); v.IsValid() {
+			r.Set(v)
+		}
+	} else if host, ok := p.err.(PanicHost); ok {
+		if host.value != nil {
+			r.Set(reflect.ValueOf(host.value))
+		}
+	} else {
+		r.Set(reflect.ValueOf(p.err))
+	}
+	return r
+}
+
+// Execute the checked statements of list in the block scope env. Returns
+// the branch which left the list early, if any.
+func evalStmtList(ctx *Ctx, list []ast.Stmt, env *Env, f *frame) (branch, error) {
+	for _, stmt := range list {
+		if b, err := evalStmt(ctx, stmt, env, f); err != nil || b.tok != token.ILLEGAL {
+			return b, err
+		}
+	}
+	return branch{}, nil
+}
+
+func evalStmt(ctx *Ctx, stmt ast.Stmt, env *Env, f *frame) (branch, error) {
+	var err error
+	switch s := stmt.(type) {
+	case *ast.EmptyStmt:
+	case *ExprStmt:
+		_, _, err = EvalExpr(ctx, s.X.(Expr), env)
+	case *AssignStmt:
+		err = evalAssignStmt(ctx, s, env)
+	case *DeclStmt:
+		for i, spec := range s.vars {
+			if err = declareVars(ctx, s.Decl.(*ast.GenDecl).Specs[i].(*ast.ValueSpec), spec, env); err != nil {
+				break
+			}
+		}
+	case *BlockStmt:
+		return evalStmtList(ctx, s.List, NewScope(env), f)
+	case *SendStmt:
+		err = evalSendStmt(ctx, s, env)
+	case *SelectStmt:
+		return evalSelectStmt(ctx, s, env, f)
+	case *DeferStmt:
+		var d deferredCall
+		if d, err = prepareCall(ctx, s.call, env); err == nil {
+			f.deferred = append(f.deferred, d)
+		}
+	case *GoStmt:
+		var d deferredCall
+		if d, err = prepareCall(ctx, s.call, env); err == nil {
+			// Registered before it starts, so that it is never missed
+			// by deadlock detection
+			g := startGoroutine(at(ctx, s.call).Source())
+			go runGoroutine(ctx, g, d)
+		}
+	case *ReturnStmt:
+		return evalReturnStmt(ctx, s, env, f)
+	case *IfStmt:
+		return evalIfStmt(ctx, s, env, f)
+	case *ForStmt:
+		return evalForStmt(ctx, s, env, f)
+	case *RangeStmt:
+		return evalRangeStmt(ctx, s, env, f)
+	case *SwitchStmt:
+		return evalSwitchStmt(ctx, s, env, f)
+	case *BranchStmt:
+		b := branch{tok: s.Tok}
+		if s.Label != nil {
+			b.label = s.Label.Name
+		}
+		return b, nil
+	default:
+		panic(dytc("unchecked statement"))
+	}
+	return branch{}, err
+}
+
+// Does b leave the loop or switch labelled label. Unlabelled branches
+// leave the innermost.
+func (b branch) targets(tok token.Token, label string) bool {
+	return b.tok == tok && (b.label == "" || b.label == label)
+}
+
+// The lhs of an assignment, with its index and pointer operands evaluated
+type assignTarget struct {
+	// A settable value, or for a map index, the map and key
+	v    reflect.Value
+	m, k reflect.Value
+}
+
+func evalAssignTarget(ctx *Ctx, lhs Expr, env *Env) (assignTarget, error) {
+	if lhs == nil {
+		// The blank identifier
+		return assignTarget{}, nil
+	}
+	if index, ok := skipSuperfluousParens(lhs).(*IndexExpr); ok {
+		if t := index.X.(Expr).KnownType()[0]; t.Kind() == reflect.Map {
+			xs, _, err := EvalExpr(ctx, index.X.(Expr), env)
+			if err != nil {
+				return assignTarget{}, err
+			}
+			k, err := evalTypedExpr(ctx, index.Index.(Expr), knownType{t.Key()}, env)
+			if err != nil {
+				return assignTarget{}, err
+			}
+			return assignTarget{m: (*xs)[0], k: k[0]}, nil
+		}
+	}
+	xs, _, err := EvalExpr(ctx, lhs, env)
+	if err != nil {
+		return assignTarget{}, err
+	}
+	return assignTarget{v: (*xs)[0]}, nil
+}
+
+func (target assignTarget) set(x reflect.Value) error {
+	if target.m.IsValid() {
+		if target.m.IsNil() {
+			return PanicAssignToNilMap{}
+		}
+		if !x.IsValid() {
+			x = reflect.Zero(target.m.Type().Elem())
+		}
+		target.m.SetMapIndex(target.k, x)
+	} else if target.v.IsValid() {
+		if !x.IsValid() {
+			x = reflect.Zero(target.v.Type())
+		}
+		target.v.Set(x)
+	}
+	return nil
+}
+
+// Assignments proceed in two phases, as in Go. The operands of index
+// expressions and pointer indirections on the lhs are evaluated along with
+// the rhs, then the assignments are carried out left to right.
+func evalAssignStmt(ctx *Ctx, a *AssignStmt, env *Env) error {
+	targets := make([]assignTarget, len(a.lhs))
+	for i, lhs := range a.lhs {
+		var err error
+		if targets[i], err = evalAssignTarget(ctx, lhs, env); err != nil {
+			return err
+		}
+	}
+
+	var xs []reflect.Value
+	var err error
+	if a.opExpr != nil {
+		xs, err = evalTypedExpr(ctx, a.opExpr, knownType{a.types[0]}, env)
+	} else {
+		xs, err = evalAssignExprs(ctx, a.vars, env)
+	}
+	if err != nil {
+		return err
+	}
+
+	for i, target := range targets {
+		if a.Tok == token.DEFINE && a.lhs[i] == nil && !isBlank(a.Lhs[i]) {
+			ptr := reflect.New(a.types[i])
+			if xs[i].IsValid() {
+				ptr.Elem().Set(xs[i])
+			}
+			env.Vars[a.Lhs[i].(*ast.Ident).Name] = ptr
+		} else if err := target.set(xs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func evalReturnStmt(ctx *Ctx, s *ReturnStmt, env *Env, f *frame) (branch, error) {
+	if s.vars != nil {
+		xs, err := evalAssignExprs(ctx, s.vars, env)
+		if err != nil {
+			return branch{}, err
+		}
+		for i, x := range xs {
+			if x.IsValid() {
+				f.results[i].Elem().Set(x)
+			} else {
+				f.results[i].Elem().Set(reflect.Zero(f.results[i].Elem().Type()))
+			}
+		}
+	}
+	return branch{tok: token.RETURN}, nil
+}
+
+func evalCondition(ctx *Ctx, cond ast.Expr, env *Env) (bool, error) {
+	xs, err := evalTypedExpr(ctx, cond.(Expr), knownType{boolType}, env)
+	if err != nil {
+		return false, err
+	}
+	return xs[0].Bool(), nil
+}
+
+func evalIfStmt(ctx *Ctx, s *IfStmt, env *Env, f *frame) (branch, error) {
+	scope := NewScope(env)
+	if s.Init != nil {
+		if _, err := evalStmt(ctx, s.Init, scope, f); err != nil {
+			return branch{}, err
+		}
+	}
+	if ok, err := evalCondition(ctx, s.Cond, scope); err != nil {
+		return branch{}, err
+	} else if ok {
+		return evalStmtList(ctx, s.Body.List, NewScope(scope), f)
+	} else if s.Else != nil {
+		return evalStmt(ctx, s.Else, scope, f)
+	}
+	return branch{}, nil
+}
+
+func evalForStmt(ctx *Ctx, s *ForStmt, env *Env, f *frame) (branch, error) {
+	scope := NewScope(env)
+	if s.Init != nil {
+		if _, err := evalStmt(ctx, s.Init, scope, f); err != nil {
+			return branch{}, err
+		}
+	}
+	for {
+		if s.Cond != nil {
+			if ok, err := evalCondition(ctx, s.Cond, scope); err != nil || !ok {
+				return branch{}, err
+			}
+		}
+		b, err := evalStmtList(ctx, s.Body.List, NewScope(scope), f)
+		if err != nil || b.targets(token.BREAK, s.label) {
+			return branch{}, err
+		} else if b.tok != token.ILLEGAL && !b.targets(token.CONTINUE, s.label) {
+			return b, nil
+		}
+
+		// Each iteration has its own copy of the loop variables, so that
+		// closures and pointers taken in one iteration are unaffected by
+		// the next.
+		next := NewScope(env)
+		for name, ptr := range scope.Vars {
+			copied := reflect.New(ptr.Elem().Type())
+			copied.Elem().Set(ptr.Elem())
+			next.Vars[name] = copied
+		}
+		scope = next
+		if s.Post != nil {
+			if _, err := evalStmt(ctx, s.Post, scope, f); err != nil {
+				return branch{}, err
+			}
+		}
+	}
+}
+
+func evalRangeStmt(ctx *Ctx, s *RangeStmt, env *Env, f *frame) (branch, error) {
+	x := s.X.(Expr)
+	xT := s.keyT
+	if !x.IsConst() {
+		xT = x.KnownType()[0]
+	}
+	xs, err := evalTypedExpr(ctx, x, knownType{xT}, env)
+	if err != nil {
+		return branch{}, err
+	}
+	v := xs[0]
+	if v.Kind() == reflect.Ptr {
+		// Short hand for array pointers
+		if v.IsNil() {
+			return branch{}, PanicInvalidDereference{}
+		}
+		v = v.Elem()
+	}
+
+	// Execute the body for one key and value, in a fresh scope
+	iterate := func(key, value reflect.Value) (bool, branch, error) {
+		scope := NewScope(env)
+		vars := []ast.Expr{s.Key, s.Value}
+		values := []reflect.Value{key, value}
+		for i, lhs := range []Expr{s.key, s.value} {
+			if s.Tok == token.DEFINE {
+				if vars[i] != nil && !isBlank(vars[i]) {
+					ptr := reflect.New(values[i].Type())
+					ptr.Elem().Set(values[i])
+					scope.Vars[vars[i].(*ast.Ident).Name] = ptr
+				}
+			} else if target, err := evalAssignTarget(ctx, lhs, env); err != nil {
+				return false, branch{}, err
+			} else if err := target.set(values[i]); err != nil {
+				return false, branch{}, err
+			}
+		}
+		b, err := evalStmtList(ctx, s.Body.List, NewScope(scope), f)
+		if err != nil || b.targets(token.BREAK, s.label) {
+			return false, branch{}, err
+		} else if b.tok != token.ILLEGAL && !b.targets(token.CONTINUE, s.label) {
+			return false, b, nil
+		}
+		return true, branch{}, nil
+	}
+
+	more := true
+	var b branch
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
+		n := v.Len()
+		for i := 0; more && err == nil && i < n; i += 1 {
+			more, b, err = iterate(reflect.ValueOf(i), v.Index(i))
+		}
+	case reflect.String:
+		for i, r := range v.String() {
+			if more, b, err = iterate(reflect.ValueOf(i), reflect.ValueOf(r)); !more || err != nil {
+				break
+			}
+		}
+	case reflect.Map:
+		for iter := v.MapRange(); more && err == nil && iter.Next(); {
+			more, b, err = iterate(iter.Key(), iter.Value())
+		}
+	case reflect.Chan:
+		for more && err == nil {
+			var elem reflect.Value
+			var ok bool
+			if elem, ok, err = recv(ctx, s, v, env); err != nil || !ok {
+				break
+			}
+			more, b, err = iterate(elem, reflect.Value{})
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := v.Int()
+		for i := int64(0); more && err == nil && i < n; i += 1 {
+			more, b, err = iterate(reflect.ValueOf(i).Convert(v.Type()), reflect.Value{})
+		}
+	default:
+		n := v.Uint()
+		for i := uint64(0); more && err == nil && i < n; i += 1 {
+			more, b, err = iterate(reflect.ValueOf(i).Convert(v.Type()), reflect.Value{})
+		}
+	}
+	return b, err
+}
+
+func evalSwitchStmt(ctx *Ctx, s *SwitchStmt, env *Env, f *frame) (branch, error) {
+	scope := NewScope(env)
+	if s.Init != nil {
+		if _, err := evalStmt(ctx, s.Init, scope, f); err != nil {
+			return branch{}, err
+		}
+	}
+	var tag reflect.Value
+	if s.Tag != nil {
+		tags, err := evalTypedExpr(ctx, s.Tag.(Expr), knownType{s.tagT}, scope)
+		if err != nil {
+			return branch{}, err
+		}
+		tag = tags[0]
+	}
+
+	matched := -1
+	for i, clause := range s.clauses {
+		if clause.List == nil {
+			if matched == -1 {
+				matched = i
+			}
+			continue
+		}
+		for j, value := range clause.values {
+			ok, err := evalCaseValue(ctx, tag, value, clause.types[j], scope)
+			if err != nil {
+				return branch{}, err
+			} else if ok {
+				matched = i
+				goto found
+			}
+		}
+	}
+	// No case matched, and there is no default
+	if matched == -1 {
+		return branch{}, nil
+	}
+
+found:
+	for i := matched; i < len(s.clauses); i += 1 {
+		clause := s.clauses[i]
+		b, err := evalStmtList(ctx, clause.body, NewScope(scope), f)
+		if err != nil || b.targets(token.BREAK, s.label) {
+			return branch{}, err
+		} else if b.tok != token.ILLEGAL || !clause.fallsThrough {
+			return b, nil
+		}
+	}
+	return branch{}, nil
+}
+
+// Compare tag to a case value in type t. An invalid tag is a switch with no
+// tag, where the cases are conditions.
+func evalCaseValue(ctx *Ctx, tag reflect.Value, value Expr, t reflect.Type, env *Env) (bool, error) {
+	xs, err := evalTypedExpr(ctx, value, knownType{t}, env)
+	if err != nil {
+		return false, err
+	}
+	x := xs[0]
+	if !tag.IsValid() {
+		return x.Bool(), nil
+	}
+	if tag.Type() != t {
+		// A concrete tag compared to an interface case value, or vice versa
+		converted := reflect.New(t).Elem()
+		converted.Set(tag)
+		tag = converted
+	}
+	if value.KnownType()[0] == ConstNil {
+		return tag.IsNil(), nil
+	} else if !x.IsValid() {
+		x = reflect.Zero(tag.Type())
+	}
+	if t := areDynamicTypesComparable(tag, x); t != nil {
+		return false, PanicUncomparableType{t}
+	}
+	return tag.Interface() == x.Interface(), nil
+}
+
+// Evaluate a map index, type assertion or receive in its comma ok form
+func evalCommaOk(ctx *Ctx, expr Expr, env *Env) (reflect.Value, bool, error) {
+	switch x := skipSuperfluousParens(expr).(type) {
+	case *IndexExpr:
+		xs, _, err := EvalExpr(ctx, x.X.(Expr), env)
+		if err != nil {
+			return reflect.Value{}, false, err
+		}
+		return evalMapIndex(ctx, (*xs)[0], x, env)
+	case *TypeAssertExpr:
+		return evalTypeAssertOk(ctx, x, env)
+	case *UnaryExpr:
+		return evalRecvOk(ctx, x, env)
+	}
+	panic(dytc("comma ok of " + expr.String()))
+}
+
+func evalSendStmt(ctx *Ctx, s *SendStmt, env *Env) error {
+	c, err := evalSendCase(ctx, s, env)
+	if err != nil {
+		return err
+	}
+	_, _, _, err = chanSelect(ctx, env.callFrame().g, s, []reflect.SelectCase{c})
+	return err
+}
+
+func evalSendCase(ctx *Ctx, s *SendStmt, env *Env) (reflect.SelectCase, error) {
+	chs, _, err := EvalExpr(ctx, s.Chan.(Expr), env)
+	if err != nil {
+		return reflect.SelectCase{}, err
+	}
+	x, err := evalTypedExpr(ctx, s.Value.(Expr), knownType{s.elemT}, env)
+	if err != nil {
+		return reflect.SelectCase{}, err
+	}
+	return reflect.SelectCase{Dir: reflect.SelectSend, Chan: (*chs)[0], Send: x[0]}, nil
+}
+
+// The channel and send operands of every clause are evaluated once, in
+// source order, before one clause is chosen. As when checked, each clause
+// has its own scope.
+func evalSelectStmt(ctx *Ctx, s *SelectStmt, env *Env, f *frame) (branch, error) {
+	cases := make([]reflect.SelectCase, len(s.clauses))
+	scopes := make([]*Env, len(s.clauses))
+	for i, clause := range s.clauses {
+		scopes[i] = NewScope(env)
+		if clause.send != nil {
+			c, err := evalSendCase(ctx, clause.send, scopes[i])
+			if err != nil {
+				return branch{}, err
+			}
+			cases[i] = c
+		} else if clause.recv != nil {
+			chs, _, err := EvalExpr(ctx, clause.recv.X.(Expr), scopes[i])
+			if err != nil {
+				return branch{}, err
+			}
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: (*chs)[0]}
+		} else {
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectDefault}
+		}
+	}
+	chosen, v, ok, err := chanSelect(ctx, f.g, s, cases)
+	if err != nil {
+		return branch{}, err
+	}
+
+	clause, scope := s.clauses[chosen], scopes[chosen]
+	if a, isAssign := clause.Comm.(*AssignStmt); isAssign {
+		if err := evalRecvAssign(ctx, a, v, ok, scope); err != nil {
+			return branch{}, err
+		}
+	}
+	b, err := evalStmtList(ctx, clause.Body, scope, f)
+	if err != nil || b.targets(token.BREAK, s.label) {
+		return branch{}, err
+	}
+	return b, nil
+}
+
+// Assign the value and ok received by a select clause v, ok = <-ch, or
+// declare them for v, ok := <-ch
+func evalRecvAssign(ctx *Ctx, a *AssignStmt, v reflect.Value, ok bool, env *Env) error {
+	xs := []reflect.Value{v}
+	if len(a.Lhs) == 2 {
+		xs = append(xs, reflect.ValueOf(ok).Convert(a.vars[1].t))
+	}
+	for i, lhs := range a.lhs {
+		if a.Tok == token.DEFINE && lhs == nil && !isBlank(a.Lhs[i]) {
+			ptr := reflect.New(a.types[i])
+			ptr.Elem().Set(xs[i])
+			env.Vars[a.Lhs[i].(*ast.Ident).Name] = ptr
+			continue
+		}
+		target, err := evalAssignTarget(ctx, lhs, env)
+		if err != nil {
+			return err
+		} else if err := target.set(xs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run reflect.Select on cases, the channel operation node of the
+// goroutine g. This blocks unless one of the cases is a default, in which
+// case g is tracked as blocked until it proceeds. A blocked select is
+// abandoned once ctx.Context is done, or if ctx.DetectDeadlock and it
+// deadlocks.
+func chanSelect(ctx *Ctx, g *goroutine, node ast.Node, cases []reflect.SelectCase) (chosen int, v reflect.Value, ok bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, isErr := r.(error); !isErr || e.Error() != "send on closed channel" {
+				panic(r)
+			}
+			err = PanicSendOnClosedChannel{}
+		}
+	}()
+	n := len(cases)
+	for _, c := range cases {
+		if c.Dir == reflect.SelectDefault {
+			chosen, v, ok = reflect.Select(cases)
+			return chosen, v, ok, nil
+		}
+	}
+
+	// Only operations which cannot proceed immediately are tracked
+	poll := reflect.SelectCase{Dir: reflect.SelectDefault}
+	if chosen, v, ok = reflect.Select(append(cases[:n:n], poll)); chosen != n {
+		return chosen, v, ok, nil
+	}
+	deadlock := g.block(describeChanOp(ctx, node))
+	defer g.unblock()
+
+	// A case without a Chan is ignored by reflect.Select
+	detect := reflect.SelectCase{Dir: reflect.SelectRecv}
+	if ctx.DetectDeadlock {
+		detect.Chan = reflect.ValueOf(deadlock)
+	}
+	cases = append(cases[:n:n], detect)
+	if ctx.Context != nil {
+		done := reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Context.Done())}
+		cases = append(cases, done)
+	}
+	switch chosen, v, ok = reflect.Select(cases); chosen {
+	case n:
+		return 0, reflect.Value{}, false, PanicDeadlock{}
+	case n + 1:
+		return 0, reflect.Value{}, false, PanicCanceled{ctx.Context.Err()}
+	}
+	return chosen, v, ok, nil
+}
+
+// Evaluate the function and arguments of call for a defer or go statement
+func prepareCall(ctx *Ctx, call *CallExpr, env *Env) (deferredCall, error) {
+	if call.isBuiltin {
+		return prepareBuiltinCall(ctx, call, env)
+	}
+	v, _, err := EvalExpr(ctx, call.Fun.(Expr), env)
+	if err != nil {
+		return nil, err
+	}
+	fun := (*v)[0]
+	args, err := evalCallArgs(ctx, call, fun.Type(), env)
+	if err != nil {
+		return nil, err
+	}
+	for i, arg := range args {
+		args[i] = detach(arg)
+	}
+	if !call.argNEllipsis {
+		args = packVariadic(fun.Type(), args)
+	}
+	return func(caller *frame, p *panicState) error {
+		if fun.IsNil() {
+			return PanicInvalidDereference{}
+		} else if fn, ok := lookupInterpFunc(fun); ok {
+			_, err := fn.invoke(ctx, args, caller, p)
+			return err
+		}
+		_, err := callFunc(ctx, fun, args, fun.Type().IsVariadic(), caller)
+		return err
+	}, nil
+}
+
+// Run the call of a go statement. Go would crash the program if the
+// goroutine panics, instead the panic is printed to ctx.Stderr and the
+// goroutine exits.
+func runGoroutine(ctx *Ctx, g *goroutine, call deferredCall) {
+	defer g.exit()
+	defer func() {
+		if r := recover(); r != nil {
+			printGoroutinePanic(ctx, r)
+		}
+	}()
+	if err := call(&frame{g: g}, nil); err != nil {
+		printGoroutinePanic(ctx, err)
+	}
+}
+
+func printGoroutinePanic(ctx *Ctx, r interface{}) {
+	fmt.Fprintf(ctxStderr(ctx), "panic: %v [in interpreted goroutine]\n", r)
+}
+
+// A copy of v, so that an argument evaluated from a variable by a defer or
+// go statement does not see later assignments to it
+func detach(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
@@ -31,3 +31,13 @@ func evalTypeAssertExpr(ctx *Ctx, assert *TypeAssertExpr, env *Env) (reflect.Val
 		return r, nil
 	}
 }
+
+// The comma ok form of a type assertion, v, ok := x.(T). Failed assertions
+// give the zero T and false rather than panicking.
+func evalTypeAssertOk(ctx *Ctx, assert *TypeAssertExpr, env *Env) (reflect.Value, bool, error) {
+	r, err := evalTypeAssertExpr(ctx, assert, env)
+	if _, ok := err.(PanicInterfaceConversion); ok {
+		return reflect.New(assert.KnownType()[0]).Elem(), false, nil
+	}
+	return r, err == nil, err
+}
@@ -3,6 +3,7 @@ package eval
 import (
 	"reflect"
 
+	"go/ast"
 	"go/token"
 )
 
@@ -21,13 +22,8 @@ func evalUnaryExpr(ctx *Ctx, unary *UnaryExpr, env *Env) ([]reflect.Value, error
 	if unary.Op == token.AND {
 		return []reflect.Value{x.Addr()}, nil
 	} else if unary.Op == token.ARROW {
-		// TODO[crc] use x.Recv in contexts where blocking is acceptable
-		v, _ := x.TryRecv()
-		if !v.IsValid() {
-			v = reflect.New(x.Type().Elem()).Elem()
-		}
-		// TODO[crc] also return ok once assignability context is implemented
-		return []reflect.Value{v}, nil
+		v, _, err := recv(ctx, unary, x, env)
+		return []reflect.Value{v}, err
 	}
 
 	var r reflect.Value
@@ -118,3 +114,41 @@ func evalUnaryBoolExpr(ctx *Ctx, x reflect.Value, op token.Token) (reflect.Value
 	}
 	return reflect.ValueOf(r).Convert(x.Type()), err
 }
+
+// The comma ok form of a receive, v, ok := <-ch
+func evalRecvOk(ctx *Ctx, unary *UnaryExpr, env *Env) (reflect.Value, bool, error) {
+	xx, _, err := EvalExpr(ctx, unary.X.(Expr), env)
+	if err != nil {
+		return reflect.Value{}, false, err
+	}
+	return recv(ctx, unary, (*xx)[0], env)
+}
+
+// Receive from ch. In the body of an interpreted function this blocks as
+// in Go. Elsewhere, such as in an expression typed into a debugger, it
+// blocks only if ctx.DetectDeadlock or ctx.Context bound the wait, and
+// otherwise fails with a PanicWouldBlock if nothing can be received.
+func recv(ctx *Ctx, node ast.Node, ch reflect.Value, env *Env) (reflect.Value, bool, error) {
+	var v reflect.Value
+	var ok bool
+	if f := env.callFrame(); f != nil || ctx.DetectDeadlock || ctx.Context != nil {
+		var g *goroutine
+		if f != nil {
+			g = f.g
+		} else {
+			g = startGoroutine("")
+			defer g.exit()
+		}
+		var err error
+		cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: ch}}
+		if _, v, ok, err = chanSelect(ctx, g, node, cases); err != nil {
+			return reflect.Value{}, false, err
+		}
+	} else if v, ok = ch.TryRecv(); !v.IsValid() {
+		return reflect.Value{}, false, PanicWouldBlock{}
+	}
+	if !v.IsValid() {
+		v = reflect.New(ch.Type().Elem()).Elem()
+	}
+	return v, ok, nil
+}
@@ -1,7 +1,10 @@
 package eval
 
 import (
+	"context"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestIntUnaryOps(t *testing.T) {
@@ -21,3 +24,29 @@ func TestUintUnaryOps(t *testing.T) {
 		expectResult(t, "uint64(+12)",  env, uint64(+12))
 	}
 }
+
+func TestRecvOutsideFunc(t *testing.T) {
+	env := makeEnv()
+	c := make(chan int, 1)
+	env.Vars["c"] = reflect.ValueOf(&c)
+
+	c <- 1
+	expectResult(t, "<-c", env, 1)
+	expectPanic(t, "<-c", env, "receive would block: nothing was received")
+
+	expectPanicCtx(t, &Ctx{Input: "<-c", DetectDeadlock: true}, env, "all goroutines are asleep - deadlock!")
+	cctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	expectPanicCtx(t, &Ctx{Input: "<-c", Context: cctx}, env, "blocked channel operation abandoned: context deadline exceeded")
+
+	// A host goroutine is not seen by deadlock detection, but can unblock
+	// a receive bounded by a Context
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		c <- 2
+	}()
+	expectResultCtx(t, &Ctx{Input: "<-c", Context: context.Background()}, env, 2)
+
+	close(c)
+	expectResults(t, "<-c", env, &[]interface{}{0})
+}
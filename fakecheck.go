@@ -61,12 +61,10 @@ func fakeCheckExpr(expr ast.Expr, env *Env) Expr {
 	case *ast.CallExpr:
 		c := &CallExpr{CallExpr: expr}
 		if ident, ok := c.Fun.(*ast.Ident); ok {
-			if _, ok := builtinFuncs[ident.Name]; ok {
-				c.isBuiltin = true
-			}
+			c.isBuiltin = isBuiltinFunc(ident.Name, env)
 		}
 		if !c.isBuiltin {
-			if _, t, isType, _ := checkType(&Ctx{""}, uncheckType(c.Fun), env); isType {
+			if _, t, isType, _ := checkType(&Ctx{}, uncheckType(c.Fun), env); isType {
 				c.isTypeConversion = true
 				c.knownType = knownType{t}
 			}
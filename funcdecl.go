@@ -0,0 +1,354 @@
+package eval
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+
+	"go/ast"
+)
+
+// The call depth limit used if Ctx.MaxCallDepth is zero
+const defaultMaxCallDepth = 10000
+
+// A function declared by EvalDecls, or a closure made from a func literal,
+// called through reflect.MakeFunc
+type interpFunc struct {
+	// The options it was declared with. Calls run with the options of
+	// their caller, and take only Input from here, which positions in the
+	// body refer to.
+	ctx  *Ctx
+	name string
+	t    reflect.Type
+
+	// The scope the function was declared in
+	env *Env
+
+	// Parameter and result names, "" for unnamed ones
+	params, results []string
+	body            []ast.Stmt
+
+	// Set once the body has checked. A func bound ahead of its
+	// declaration by declareFuncs cannot be called before then.
+	defined bool
+}
+
+// A func bound by declareFuncs, and how to undo the binding
+type pendingFunc struct {
+	fn      *interpFunc
+	restore func()
+}
+
+// Interpreted functions by their MakeFunc value, normalized by
+// funcKey. Interpreted code calls these directly rather than through
+// reflect, so that the callee joins the caller's call chain.
+var interpFuncs sync.Map
+
+// All MakeFunc values share a code pointer, which distinguishes them
+// cheaply from ordinary funcs.
+var makeFuncCode = reflect.MakeFunc(reflect.TypeOf(func() {}), nil).Pointer()
+
+// Values of the same func obtained in different ways, such as from a map
+// or a variable, may differ. Round tripping through an interface gives
+// the same Value for each.
+func funcKey(fun reflect.Value) reflect.Value {
+	return reflect.ValueOf(fun.Interface())
+}
+
+func lookupInterpFunc(fun reflect.Value) (*interpFunc, bool) {
+	if fun.Pointer() != makeFuncCode || !fun.CanInterface() {
+		return nil, false
+	}
+	fn, ok := interpFuncs.Load(funcKey(fun))
+	if !ok {
+		return nil, false
+	}
+	return fn.(*interpFunc), true
+}
+
+// Remove fun from interpFuncs once its declaration is replaced. Values of
+// fun held elsewhere remain callable, through reflect.
+func forgetInterpFunc(fun reflect.Value) {
+	if fun.Pointer() == makeFuncCode && fun.CanInterface() {
+		interpFuncs.Delete(funcKey(fun))
+	}
+}
+
+// Runtime errors in interpreted functions called by host code are carried
+// out of the reflect.Value.Call which invoked them as a panic, and are
+// recovered by callFunc. Host code calling an interpreted function directly
+// sees this panic, which wraps the Panic error of the function.
+type funcPanic struct {
+	err error
+}
+
+func (p funcPanic) Error() string {
+	return p.err.Error()
+}
+
+func (p funcPanic) Unwrap() error {
+	return p.err
+}
+
+// Bind the funcs of decls in env before any declaration is evaluated, so
+// that their bodies may call funcs declared after them. Funcs are left to
+// be bound in order if their name is declared more than once, or if their
+// signature does not check yet or refers to a name declared by decls.
+func declareFuncs(ctx *Ctx, decls []ast.Decl, env *Env) map[*ast.FuncDecl]*pendingFunc {
+	funcs := map[string]int{}
+	declared := map[string]bool{}
+	for _, decl := range decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			funcs[decl.Name.Name] += 1
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						declared[name.Name] = true
+					}
+				case *ast.TypeSpec:
+					declared[spec.Name.Name] = true
+				}
+			}
+		}
+	}
+
+	pending := map[*ast.FuncDecl]*pendingFunc{}
+	for _, decl := range decls {
+		decl, ok := decl.(*ast.FuncDecl)
+		if !ok || decl.Recv != nil || decl.Type.TypeParams != nil || decl.Body == nil {
+			continue
+		}
+		name := decl.Name.Name
+		if name == "_" || funcs[name] > 1 || declared[name] || typesReferTo(decl.Type, declared) {
+			continue
+		}
+		t, errs := checkFuncType(ctx, decl.Type, env)
+		if errs != nil {
+			continue
+		}
+		fn := &interpFunc{ctx: ctx, name: name, t: t, env: env}
+		restore := env.save(name)
+		fn.bind(env)
+		pending[decl] = &pendingFunc{fn, restore}
+	}
+	return pending
+}
+
+// Do the parameter or result types of ft contain an identifier in names
+func typesReferTo(ft *ast.FuncType, names map[string]bool) bool {
+	found := false
+	for _, fields := range []*ast.FieldList{ft.Params, ft.Results} {
+		if fields == nil {
+			continue
+		}
+		for _, field := range fields.List {
+			ast.Inspect(field.Type, func(n ast.Node) bool {
+				if ident, ok := n.(*ast.Ident); ok && names[ident.Name] {
+					found = true
+				}
+				return !found
+			})
+		}
+	}
+	return found
+}
+
+// Bind fn in env under its name, as a MakeFunc value known to interpFuncs
+func (fn *interpFunc) bind(env *Env) {
+	f := reflect.MakeFunc(fn.t, fn.call)
+	interpFuncs.Store(funcKey(f), fn)
+	env.bindValue(env.Funcs, fn.name, f)
+}
+
+// Check the body of decl and bind the function in env. The function is
+// bound before its body is checked, so that it may call itself, unless
+// declareFuncs bound it already as pending. If the body fails to check,
+// any previous binding of the name is restored.
+func evalFuncDecl(ctx *Ctx, decl *ast.FuncDecl, env *Env, pending *pendingFunc) error {
+	if decl.Recv != nil {
+		return errors.New("method declarations not implemented")
+	} else if decl.Type.TypeParams != nil {
+		return errors.New("generic func declarations not implemented")
+	} else if decl.Body == nil {
+		return errors.New("func declarations without a body not implemented")
+	}
+
+	var fn *interpFunc
+	var restore func()
+	if pending != nil {
+		fn, restore = pending.fn, pending.restore
+	} else {
+		t, errs := checkFuncType(ctx, decl.Type, env)
+		if errs != nil {
+			return CheckErrors(errs)
+		}
+		fn = &interpFunc{ctx: ctx, name: decl.Name.Name, t: t, env: env}
+		restore = env.save(fn.name)
+		if fn.name != "_" {
+			fn.bind(env)
+		}
+	}
+
+	var errs []error
+	fn.params, fn.results, errs = checkFuncBody(ctx, decl.Type, fn.t, decl.Body, env)
+	if errs != nil {
+		restore()
+		return CheckErrors(errs)
+	}
+	fn.body = decl.Body.List
+	fn.defined = true
+	return nil
+}
+
+// Check body in place, as that of a function of type t with signature ft
+// declared in the scope env. Returns the parameter and result names.
+func checkFuncBody(ctx *Ctx, ft *ast.FuncType, t reflect.Type, body *ast.BlockStmt, env *Env) (params, results []string, errs []error) {
+	scope := NewScope(env)
+	params = declareFields(ft.Params, t.In, scope)
+	results = declareFields(ft.Results, t.Out, scope)
+	sc := &stmtCtx{named: len(results) > 0 && results[0] != ""}
+	for i := range results {
+		sc.results = append(sc.results, t.Out(i))
+	}
+
+	errs = checkStmtList(ctx, body.List, scope, sc)
+	if errs == nil && len(results) > 0 && !isTerminatingList(body.List) {
+		errs = []error{ErrMissingReturn{at(ctx, body)}}
+	}
+	return params, results, errs
+}
+
+// Names of the parameters or results in fields, in order, with
+// placeholders for the named ones bound in the check scope env.
+func declareFields(fields *ast.FieldList, typeOf func(int) reflect.Type, env *Env) []string {
+	var names []string
+	if fields == nil {
+		return nil
+	}
+	for _, field := range fields.List {
+		if field.Names == nil {
+			names = append(names, "")
+		}
+		for _, name := range field.Names {
+			if name.Name != "_" {
+				env.Vars[name.Name] = reflect.New(typeOf(len(names)))
+			}
+			names = append(names, name.Name)
+		}
+	}
+	return names
+}
+
+// Returns a func which restores the binding of name in env to its current
+// state.
+func (env *Env) save(name string) func() {
+	v, isVar := env.Vars[name]
+	c, isConst := env.Consts[name]
+	f, isFunc := env.Funcs[name]
+	t, isType := env.Types[name]
+	var fn *interpFunc
+	if isFunc {
+		fn, _ = lookupInterpFunc(f)
+	}
+	return func() {
+		envMu.Lock()
+		defer envMu.Unlock()
+		env.unbind(name)
+		if isVar {
+			env.Vars[name] = v
+		} else if isConst {
+			env.Consts[name] = c
+		} else if isFunc {
+			env.Funcs[name] = f
+			if fn != nil {
+				interpFuncs.Store(funcKey(f), fn)
+			}
+		} else if isType {
+			env.Types[name] = t
+		}
+	}
+}
+
+// The MakeFunc implementation of fn, for calls from host code. Host code
+// called by an evaluation continues its call chain and options, otherwise
+// fn runs with the options it was declared with.
+func (fn *interpFunc) call(args []reflect.Value) []reflect.Value {
+	ctx, caller := fn.ctx, (*frame)(nil)
+	if c := currentHostCall(); c != nil {
+		ctx, caller = c.ctx, c.caller
+	}
+	out, err := fn.invoke(ctx, args, caller, nil)
+	if host, ok := err.(PanicHost); ok {
+		panic(host.value)
+	} else if err != nil {
+		panic(funcPanic{err})
+	}
+	return out
+}
+
+// Call fn with the options of ctx from the interpreted call frame caller,
+// or nil if called from host code. args are in the form taken by
+// CallSlice. If the call is deferred by a panicking frame, recoverable is
+// the panic it may recover.
+func (fn *interpFunc) invoke(ctx *Ctx, args []reflect.Value, caller *frame, recoverable *panicState) ([]reflect.Value, error) {
+	if !fn.defined {
+		return nil, PanicFuncNotDeclared{fn.name}
+	}
+	if ctx.Input != fn.ctx.Input {
+		callCtx := *ctx
+		callCtx.Input = fn.ctx.Input
+		ctx = &callCtx
+	}
+	limit := ctx.MaxCallDepth
+	if limit <= 0 {
+		limit = defaultMaxCallDepth
+	}
+	f := &frame{depth: 1, recoverable: recoverable}
+	if caller != nil {
+		f.depth, f.g = caller.depth+1, caller.g
+	}
+	if f.depth > limit {
+		return nil, PanicStackOverflow{limit}
+	} else if f.g == nil {
+		f.g = startGoroutine("")
+		defer f.g.exit()
+	}
+
+	scope := NewScope(fn.env)
+	scope.frame = f
+	for i, name := range fn.params {
+		if name != "" && name != "_" {
+			ptr := reflect.New(fn.t.In(i))
+			ptr.Elem().Set(args[i])
+			scope.Vars[name] = ptr
+		}
+	}
+	f.results = make([]reflect.Value, len(fn.results))
+	for i, name := range fn.results {
+		f.results[i] = reflect.New(fn.t.Out(i))
+		if name != "" && name != "_" {
+			scope.Vars[name] = f.results[i]
+		}
+	}
+
+	defer func() {
+		for _, c := range f.closures {
+			forgetInterpFunc(c)
+		}
+	}()
+	_, err := evalStmtList(ctx, fn.body, scope, f)
+	if err = f.runDeferred(err); err != nil {
+		if host, ok := err.(PanicHost); ok && caller == nil {
+			panic(host.value)
+		}
+		return nil, err
+	}
+	out := make([]reflect.Value, len(f.results))
+	for i, result := range f.results {
+		out[i] = result.Elem()
+	}
+	return out, nil
+}
@@ -0,0 +1,627 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEvalFuncDeclRecursive(t *testing.T) {
+	env := NewEnv()
+	err := EvalDecls(`func fib(n int) int {
+	if n < 2 {
+		return n
+	}
+	return fib(n-1) + fib(n-2)
+}`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, "fib(20)", env, 6765)
+
+	// Declared funcs are ordinary Go funcs to the host
+	fib, ok := env.Funcs["fib"].Interface().(func(int) int)
+	if !ok {
+		t.Fatalf("fib declared as %v", env.Funcs["fib"].Type())
+	}
+	if n := fib(10); n != 55 {
+		t.Fatalf("fib(10) = %d", n)
+	}
+}
+
+func TestEvalFuncDeclResults(t *testing.T) {
+	env := NewEnv()
+	err := EvalDecls(`func divmod(a, b int) (q, r int) {
+	q = a / b
+	r = a % b
+	return
+}
+func swap(a, b string) (string, string) { return b, a }
+func rdivmod(a, b int) (int, int) { return divmod(b, a) }
+func noop(int, string) {}`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResults(t, "divmod(7, 2)", env, &[]interface{}{3, 1})
+	expectResults(t, "swap(\"a\", \"b\")", env, &[]interface{}{"b", "a"})
+	expectResults(t, "rdivmod(2, 7)", env, &[]interface{}{3, 1})
+	expectResults(t, "noop(1, \"\")", env, &[]interface{}{})
+}
+
+func TestEvalFuncDeclStmts(t *testing.T) {
+	env := NewEnv()
+	err := EvalDecls(`func sum(xs ...int) (total int) {
+	for _, x := range xs {
+		total += x
+	}
+	return
+}
+func collatz(n int) (steps int) {
+	for n != 1 {
+		switch {
+		case n%2 == 0:
+			n /= 2
+		default:
+			n = 3*n + 1
+		}
+		steps++
+	}
+	return
+}
+func primes(n int) []int {
+	var ps []int
+outer:
+	for i := 2; len(ps) < n; i++ {
+		for _, p := range ps {
+			if i%p == 0 {
+				continue outer
+			}
+		}
+		ps = append(ps, i)
+	}
+	return ps
+}
+func grade(score int) string {
+	switch s := score / 10; s {
+	case 10:
+		fallthrough
+	case 9:
+		return "A"
+	case 8, 7:
+		return "B"
+	}
+	return "F"
+}
+func count(words ...string) map[string]int {
+	m := map[string]int{}
+	for _, w := range words {
+		if n, ok := m[w]; ok {
+			m[w] = n + 1
+		} else {
+			m[w] = 1
+		}
+	}
+	return m
+}
+func runes(s string) (n int) {
+	for range s {
+		n++
+	}
+	return
+}
+func triangle(n int) (r int) {
+	for i := range n + 1 {
+		r += i
+	}
+	return
+}
+func ptrs() []*int {
+	var ps []*int
+	for i := 0; i < 3; i++ {
+		ps = append(ps, &i)
+	}
+	return ps
+}
+func first(xs []int, pred func(int) bool) int {
+	for i, x := range xs {
+		if pred(x) {
+			return i
+		}
+	}
+	return -1
+}
+func even(x int) bool { return x%2 == 0 }`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, "sum(1, 2, 3)", env, 6)
+	expectResult(t, "collatz(27)", env, 111)
+	expectResult(t, "primes(5)", env, []int{2, 3, 5, 7, 11})
+	expectResult(t, "grade(100) + grade(95) + grade(71) + grade(12)", env, "AABF")
+	expectResult(t, "count(\"a\", \"b\", \"a\")[\"a\"]", env, 2)
+	expectResult(t, "runes(\"héllo\")", env, 5)
+	expectResult(t, "triangle(4)", env, 10)
+	expectResult(t, "*ptrs()[0] + *ptrs()[2]", env, 2)
+	expectResult(t, "first([]int{1, 3, 4}, even)", env, 2)
+}
+
+func TestEvalFuncDeclPanics(t *testing.T) {
+	env := NewEnv()
+	err := EvalDecls(`func div(a, b int) int { return a / b }
+func set() {
+	var m map[string]int
+	m["a"] = 1
+}
+func deep(n int) int { return deep(n + 1) }`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectPanic(t, "div(1, 0)", env, "runtime error: integer divide by zero")
+	expectPanic(t, "set()", env, "assignment to entry in nil map")
+	expectPanic(t, "deep(0)", env, "runtime: goroutine stack exceeds 10000-call limit")
+	// The limit is that of the caller, and applies to the call chain, not
+	// to all calls
+	for i := 0; i < 2; i++ {
+		expectPanicCtx(t, &Ctx{Input: "deep(0)", MaxCallDepth: 100}, env,
+			"runtime: goroutine stack exceeds 100-call limit")
+	}
+
+	// Host calls see the runtime error as a panic
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.As(err, &PanicDivideByZero{}) {
+			t.Fatalf("Expected divide by zero, got %v", err)
+		}
+	}()
+	env.Funcs["div"].Interface().(func(int, int) int)(1, 0)
+}
+
+func TestEvalFuncDeclHostCallbackDepth(t *testing.T) {
+	env := NewEnv()
+	env.SetFunc("apply", func(f func(int) int, n int) int { return f(n) })
+	err := EvalDecls(`func viaHost(n int) int { return apply(viaHost, n+1) }`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Calls back through host code continue the call chain
+	expectPanicCtx(t, &Ctx{Input: "viaHost(0)", MaxCallDepth: 100}, env,
+		"runtime: goroutine stack exceeds 100-call limit")
+}
+
+func TestEvalFuncDeclErrors(t *testing.T) {
+	env := NewEnv()
+	if err := EvalDecls("func one() int { return 1 }", env); err != nil {
+		t.Fatal(err)
+	}
+	expectDeclError(t, "func f() int { }", env, "missing return at end of function")
+	expectDeclError(t, "func f() int { for { break } }", env, "missing return at end of function")
+	expectDeclError(t, "func f() int { return }", env, "not enough arguments to return")
+	expectDeclError(t, "func f() { return 1 }", env, "too many arguments to return")
+	expectDeclError(t, "func f() (int, bool) { return one() }", env, "assignment count mismatch: 2 = 1")
+	expectDeclError(t, "func f() { x := 1; x := 2 }", env, "no new variables on left side of :=")
+	expectDeclError(t, "func f() { x, x := 1, 2 }", env, "x repeated on left side of :=")
+	expectDeclError(t, "func f() { one() + 2 }", env, "one() + 2 evaluated but not used")
+	expectDeclError(t, "func f() { const c = 1; c = 2 }", env, "cannot assign to c")
+	expectDeclError(t, "func f() { if one() { } }", env, "non-bool one() (type int) used as if condition")
+	expectDeclError(t, "func f() { break }", env, "break is not in a loop, switch, or select")
+	expectDeclError(t, "func f() { switch { default: continue } }", env, "continue is not in a loop")
+	expectDeclError(t, "func f() { L: for { break M } }", env, "invalid break label M")
+	expectDeclError(t, "func f() { switch { case true: fallthrough } }", env, "cannot fallthrough final case in switch")
+	expectDeclError(t, "func f(n int, s string) { switch n { case s: } }", env, "invalid case s in switch (mismatched types string and int)")
+	expectDeclError(t, "func f(b bool) { for range b { } }", env, "cannot range over b (type bool)")
+	expectDeclError(t, "func f() { defer int(1) }", env, "expression in defer must be function call")
+	expectDeclError(t, "func f() { defer len(\"\") }", env, "defer discards result of len(\"\")")
+	expectDeclError(t, "func f(c <-chan int) { c <- 1 }", env, "invalid operation: c <- (send to receive-only type <-chan int)")
+	expectDeclError(t, "func f(c chan<- int) { <-c }", env, "invalid operation: <-c (receive from send-only type chan<- int)")
+	expectDeclError(t, "func f(c chan int, s string) { c <- s }", env, "cannot use s (type string) as type int in send")
+	expectDeclError(t, "func f() { select { default: default: } }", env, "multiple defaults in select")
+	expectDeclError(t, "func f() { select { case one(): } }", env, "select case must be receive, send or assign recv")
+	expectDeclError(t, "func (int) m() {}", env, "method declarations not implemented")
+
+	// A failed declaration leaves the previous binding
+	expectDeclError(t, "func one() int { return undefined }", env, "undefined: undefined")
+	expectResult(t, "one()", env, 1)
+	if _, ok := lookupInterpFunc(env.Funcs["one"]); !ok {
+		t.Fatalf("one is no longer an interpreted func")
+	}
+}
+
+func TestEvalFuncDeclRedeclare(t *testing.T) {
+	countInterpFuncs := func() (n int) {
+		interpFuncs.Range(func(_, _ interface{}) bool { n += 1; return true })
+		return n
+	}
+	env := NewEnv()
+	if err := EvalDecls("func f() int { return 1 }", env); err != nil {
+		t.Fatal(err)
+	}
+	old := env.Funcs["f"]
+	n := countInterpFuncs()
+	for i := 0; i < 10; i += 1 {
+		if err := EvalDecls("func f() int { return 2 }; var x = 1; func _() {}", env); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m := countInterpFuncs(); m != n {
+		t.Fatalf("Redeclaring f grew interpFuncs from %d to %d entries", n, m)
+	}
+	expectResult(t, "f()", env, 2)
+	if r := old.Call(nil)[0].Int(); r != 1 {
+		t.Fatalf("Replaced f returned %d", r)
+	}
+}
+
+func TestEvalFuncDeclForward(t *testing.T) {
+	env := NewEnv()
+	err := EvalDecls(`func first() int { return second() + 1 }
+func even(n int) bool {
+	if n == 0 {
+		return true
+	}
+	return odd(n - 1)
+}
+func odd(n int) bool {
+	if n == 0 {
+		return false
+	}
+	return even(n - 1)
+}
+func second() int { return 1 }`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, "first()", env, 2)
+	expectResult(t, "even(10)", env, true)
+	expectResult(t, "odd(10)", env, false)
+
+	expectDeclError(t, "var v = later(); func later() int { return 1 }", env,
+		"later called before its declaration")
+
+	// Funcs after a failed declaration are not declared, and calls to
+	// them from those before it fail
+	expectDeclError(t, "func a() int { return b() }; func b() int { return c }", env,
+		"undefined: c")
+	if _, ok := env.Funcs["b"]; ok {
+		t.Fatalf("Expected b to remain undeclared")
+	}
+	expectPanic(t, "a()", env, "undefined: b")
+}
+
+func TestEvalFuncDeclDefer(t *testing.T) {
+	env := NewEnv()
+	err := EvalDecls(`func push(s *[]int, n int) { *s = append(*s, n) }
+func order() (s []int) {
+	for i := 0; i < 3; i++ {
+		defer push(&s, i)
+	}
+	return []int{9}
+}
+func double(r *int) { *r *= 2 }
+func twice(x int) (r int) {
+	defer double(&r)
+	return x
+}
+func args() (s []int) {
+	x := 1
+	defer push(&s, x)
+	x = 2
+	return nil
+}
+func safeDiv(a, b int) (q int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = r.(error)
+		}
+	}()
+	return a / b, nil
+}
+func divErr(a, b int) string {
+	_, err := safeDiv(a, b)
+	return err.Error()
+}
+func save(r *interface{}) { *r = recover() }
+func catch(v interface{}) (r interface{}) {
+	defer save(&r)
+	panic(v)
+}
+func catchLit(v interface{}) (r interface{}) {
+	f := func() { r = recover() }
+	defer f()
+	panic(v)
+}
+func nested() (r interface{}) {
+	defer func() {
+		func() { r = recover() }()
+	}()
+	panic("first")
+}
+func second() { panic("second") }
+func repanic() {
+	defer second()
+	panic("first")
+}
+func notDeferred() interface{} {
+	defer recover()
+	return recover()
+}`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, "order()", env, []int{9, 2, 1, 0})
+	expectResult(t, "twice(3)", env, 6)
+	expectResult(t, "args()", env, []int{1})
+	expectResult(t, "divErr(1, 0)", env, "runtime error: integer divide by zero")
+	expectResult(t, "catch(\"boom\").(string)", env, "boom")
+	expectResult(t, "catch(1).(int)", env, 1)
+	expectResult(t, "catchLit(2).(int)", env, 2)
+	expectPanic(t, "nested()", env, "first")
+	expectResult(t, "notDeferred() == nil", env, true)
+	expectPanic(t, "repanic()", env, "second")
+}
+
+func TestEvalFuncDeclHostPanic(t *testing.T) {
+	env := NewEnv()
+	env.SetFunc("hostPanic", func() { panic("host") })
+	env.SetFunc("hostIdx", func(s []int) int { return s[0] })
+	err := EvalDecls(`func r1() (x int) {
+	defer func() {
+		if recover() != nil {
+			x = 1
+		}
+	}()
+	hostPanic()
+	return 2
+}
+func msg(s *string) { *s = recover().(error).Error() }
+func r2() (s string) {
+	defer msg(&s)
+	hostIdx(nil)
+	return ""
+}
+var ran int
+func bump() { ran++ }
+func r3() {
+	defer bump()
+	hostPanic()
+}`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, "r1()", env, 1)
+	expectResult(t, "r2()", env, "runtime error: index out of range [0] with length 0")
+
+	// Unrecovered, the panic reaches the host after the deferred calls run
+	for _, call := range []func(){
+		func() { Eval("r3()", env) },
+		env.Funcs["r3"].Interface().(func()),
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r != "host" {
+					t.Fatalf("Expected host panic, got %v", r)
+				}
+			}()
+			call()
+		}()
+	}
+	expectResult(t, "ran", env, 2)
+}
+
+func TestEvalFuncDeclGo(t *testing.T) {
+	env := NewEnv()
+	done := make(chan int)
+	env.Funcs["report"] = reflect.ValueOf(func(n int) { done <- n })
+	err := EvalDecls(`func deep(n int) int {
+	if n == 0 {
+		return 0
+	}
+	return deep(n-1) + 1
+}
+func fan(n int) {
+	for i := 0; i < n; i++ {
+		go func() { report(deep(80)) }()
+	}
+}`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResultsCtx(t, &Ctx{Input: "fan(4)", MaxCallDepth: 100}, env, &[]interface{}{})
+
+	// Each goroutine has its own call chain
+	for i := 0; i < 4; i++ {
+		if n := <-done; n != 80 {
+			t.Fatalf("worker reported %d", n)
+		}
+	}
+}
+
+func TestEvalFuncDeclSelect(t *testing.T) {
+	env := NewEnv()
+	err := EvalDecls(`func produce(n int, c chan<- int) {
+	for i := 1; i <= n; i++ {
+		c <- i
+	}
+	close(c)
+}
+func pipe(n int) (total int) {
+	c := make(chan int)
+	done := make(chan bool)
+	go produce(n, c)
+	go func() {
+		for x := range c {
+			total += x
+		}
+		done <- true
+	}()
+	<-done
+	return
+}
+func poll(c chan int) string {
+	select {
+	case x, ok := <-c:
+		if !ok {
+			return "closed"
+		}
+		return string(rune('0' + x))
+	default:
+		return "empty"
+	}
+}
+func first(a, b chan int) (which string, x int) {
+	select {
+	case x = <-a:
+		which = "a"
+	case x = <-b:
+		which = "b"
+	}
+	return
+}
+func full(c chan int) bool {
+	select {
+	case c <- 1:
+		return false
+	default:
+		return true
+	}
+}
+func loop(c chan int) (n int) {
+	for {
+		select {
+		case _, ok := <-c:
+			if !ok {
+				return
+			}
+			n++
+		}
+	}
+}
+func sendClosed() {
+	c := make(chan int)
+	close(c)
+	c <- 1
+}`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, "pipe(10)", env, 55)
+	expectResult(t, "poll(make(chan int))", env, "empty")
+	c := make(chan int, 1)
+	env.Vars["c"] = reflect.ValueOf(&c)
+	c <- 7
+	expectResult(t, "poll(c)", env, "7")
+	close(c)
+	expectResult(t, "poll(c)", env, "closed")
+	expectResult(t, "full(make(chan int))", env, true)
+	expectResult(t, "full(make(chan int, 1))", env, false)
+
+	b := make(chan int, 1)
+	b <- 2
+	env.Vars["b"] = reflect.ValueOf(&b)
+	expectResults(t, "first(nil, b)", env, &[]interface{}{"b", 2})
+
+	l := make(chan int, 3)
+	l <- 1
+	l <- 2
+	close(l)
+	env.Vars["l"] = reflect.ValueOf(&l)
+	expectResult(t, "loop(l)", env, 2)
+	expectPanic(t, "sendClosed()", env, "send on closed channel")
+}
+
+func TestEvalFuncDeclChanCancel(t *testing.T) {
+	env := NewEnv()
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	err := EvalDecls(`func block() {
+	select {}
+}
+func recvNil() int {
+	var c chan int
+	return <-c
+}`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	for _, expr := range []string{"block()", "recvNil()"} {
+		_, err := EvalCtx(&Ctx{Input: expr, Context: cancelCtx}, env)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s: expected cancellation, got %v", expr, err)
+		}
+	}
+}
+
+func TestEvalFuncDeclDeadlock(t *testing.T) {
+	env := NewEnv()
+	err := EvalDecls(`func dead() int {
+	c := make(chan int)
+	return <-c
+}
+func leakSend(c chan int) { c <- 1 }
+func leakRecv(c chan int) { <-c }
+func leak() {
+	go leakSend(make(chan int))
+}
+func both() {
+	c := make(chan string)
+	go leakRecv(make(chan int))
+	select {
+	case <-c:
+	case c <- "x":
+	}
+}`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expr := range []string{"dead()", "both()"} {
+		expectPanicCtx(t, &Ctx{Input: expr, DetectDeadlock: true}, env,
+			"all goroutines are asleep - deadlock!")
+	}
+	expectResults(t, "leak()", env, &[]interface{}{})
+
+	// Goroutines which never return are reported once blocked
+	want := map[string]string{
+		"leakRecv(make(chan int))": "<-c",
+		"leakSend(make(chan int))": "c <- 1",
+	}
+	for deadline := time.Now().Add(time.Second); ; {
+		got := map[string]string{}
+		for _, g := range Goroutines() {
+			if _, ok := want[g.Call]; ok {
+				got[g.Call] = g.BlockedIn
+			}
+		}
+		if reflect.DeepEqual(got, want) {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("Expected leaked goroutines %v, got %v", want, got)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEvalFuncDeclHostChannels(t *testing.T) {
+	env := NewEnv()
+	hc := make(chan int)
+	env.SetVar("hc", &hc)
+	env.SetFunc("after", func(ms int) <-chan time.Time {
+		return time.After(time.Duration(ms) * time.Millisecond)
+	})
+	err := EvalDecls(`func sleep() bool {
+	<-after(200)
+	return true
+}
+func recvHost() int { return <-hc }`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Blocked well beyond the grace period of deadlock detection
+	expectResult(t, "sleep()", env, true)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		hc <- 7
+	}()
+	expectResult(t, "recvHost()", env, 7)
+}
@@ -0,0 +1,205 @@
+package eval
+
+import (
+	"bytes"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go/ast"
+)
+
+// Goroutine describes a goroutine started by a go statement in an
+// interpreted function which has not yet returned. Goroutines which remain
+// after the evaluation that started them has returned are leaked, and are
+// most often blocked in a channel operation which can never proceed.
+type Goroutine struct {
+	// Increasing in the order the goroutines were started
+	ID int
+
+	// The call of the go statement, such as "worker(c)"
+	Call string
+
+	// The channel operation the goroutine is blocked in, such as "<-c",
+	// "c <- x" or "select", or "" if it is running.
+	BlockedIn string
+}
+
+// Goroutines returns the interpreted goroutines which have not yet
+// returned, in the order they were started.
+func Goroutines() []Goroutine {
+	sched.Lock()
+	defer sched.Unlock()
+	var gs []Goroutine
+	for g := range sched.live {
+		if g.call != "" {
+			gs = append(gs, Goroutine{g.id, g.call, g.blockedIn})
+		}
+	}
+	sort.Slice(gs, func(i, j int) bool { return gs[i].ID < gs[j].ID })
+	return gs
+}
+
+// A goroutine running interpreted code. This is either a host goroutine
+// which called an interpreted function, a root, or one started by a go
+// statement.
+type goroutine struct {
+	id   int
+	call string
+
+	blockedIn string
+
+	// For a root, closed when its blocked operation is deadlocked
+	deadlock chan struct{}
+}
+
+// How long every interpreted goroutine must remain blocked before this is
+// reported as a deadlock. A goroutine is counted as blocked just before
+// it parks in reflect.Select, so a goroutine which is about to unblock it
+// may briefly appear blocked too.
+const deadlockGrace = 50 * time.Millisecond
+
+// Interpreted goroutines are tracked globally. When each of them is
+// blocked in a channel operation, the program is deadlocked, as far as the
+// interpreter can tell. The blocked operations of roots evaluated with
+// Ctx.DetectDeadlock then fail with a PanicDeadlock, and the others are
+// left blocked, and so leaked.
+//
+// Host goroutines and timers which could unblock an operation are not
+// seen, which is why detection must be asked for. A host call made by
+// interpreted code counts as running, however long it blocks.
+var sched struct {
+	sync.Mutex
+	nextID  int
+	live    map[*goroutine]bool
+	blocked int
+
+	// Incremented each time a goroutine blocks or unblocks
+	gen int
+}
+
+// Register a new interpreted goroutine. call is the source of the call of
+// its go statement, or "" for a root.
+func startGoroutine(call string) *goroutine {
+	sched.Lock()
+	defer sched.Unlock()
+	if sched.live == nil {
+		sched.live = map[*goroutine]bool{}
+	}
+	sched.nextID += 1
+	g := &goroutine{id: sched.nextID, call: call}
+	if call == "" {
+		g.deadlock = make(chan struct{})
+	}
+	sched.live[g] = true
+	return g
+}
+
+func (g *goroutine) exit() {
+	sched.Lock()
+	defer sched.Unlock()
+	delete(sched.live, g)
+	sched.gen += 1
+	watchDeadlock()
+}
+
+// Mark g as blocked in the operation op. Returns the channel closed if the operation deadlocks.
+func (g *goroutine) block(op string) <-chan struct{} {
+	sched.Lock()
+	defer sched.Unlock()
+	g.blockedIn = op
+	sched.blocked += 1
+	sched.gen += 1
+	watchDeadlock()
+	return g.deadlock
+}
+
+func (g *goroutine) unblock() {
+	sched.Lock()
+	defer sched.Unlock()
+	g.blockedIn = ""
+	sched.blocked -= 1
+	sched.gen += 1
+}
+
+// If every goroutine is blocked, check again after the grace period.
+// Called with sched locked.
+func watchDeadlock() {
+	if sched.blocked > 0 && sched.blocked == len(sched.live) {
+		gen := sched.gen
+		time.AfterFunc(deadlockGrace, func() { checkDeadlock(gen) })
+	}
+}
+
+// Nothing has blocked or unblocked since generation gen, at which every
+// goroutine was blocked
+func checkDeadlock(gen int) {
+	sched.Lock()
+	defer sched.Unlock()
+	if sched.gen != gen {
+		return
+	}
+	for g := range sched.live {
+		if g.deadlock != nil {
+			close(g.deadlock)
+			g.deadlock = make(chan struct{})
+		}
+	}
+}
+
+// A call of host code made by an evaluation, in progress on some goroutine
+type hostCall struct {
+	// The options of the evaluation
+	ctx *Ctx
+
+	// The interpreted call frame making the call, nil outside of one
+	caller *frame
+}
+
+// Host calls in progress, innermost first, by the id of the goroutine
+// making them. Interpreted functions called back by the host code find
+// their caller here, so that the call chain and its depth continue.
+var hostCalls sync.Map
+
+// Record a call of host code by caller, or nil, under the options of ctx,
+// returning a func which ends it.
+func enterHostCall(ctx *Ctx, caller *frame) func() {
+	id := goid()
+	prev, _ := hostCalls.Load(id)
+	hostCalls.Store(id, &hostCall{ctx, caller})
+	return func() {
+		if prev != nil {
+			hostCalls.Store(id, prev)
+		} else {
+			hostCalls.Delete(id)
+		}
+	}
+}
+
+// The innermost host call in progress on this goroutine, or nil
+func currentHostCall() *hostCall {
+	if c, ok := hostCalls.Load(goid()); ok {
+		return c.(*hostCall)
+	}
+	return nil
+}
+
+// The id of the running goroutine. Go has no goroutine local storage, but
+// the id heads every stack trace, as "goroutine 1 [running]:".
+func goid() uint64 {
+	var buf [64]byte
+	trace := buf[len("goroutine "):runtime.Stack(buf[:], false)]
+	trace = trace[:bytes.IndexByte(trace, ' ')]
+	id, _ := strconv.ParseUint(string(trace), 10, 64)
+	return id
+}
+
+// A description of the channel operation node, for Goroutine.BlockedIn
+func describeChanOp(ctx *Ctx, node ast.Node) string {
+	if _, ok := node.(*SelectStmt); ok {
+		return "select"
+	}
+	return at(ctx, node).Source()
+}
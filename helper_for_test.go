@@ -11,7 +11,11 @@ import (
 )
 
 func getResults(t *testing.T, expr string, env *Env) *[]reflect.Value {
-	ctx := &Ctx{expr}
+	return getResultsCtx(t, &Ctx{Input: expr}, env)
+}
+
+func getResultsCtx(t *testing.T, ctx *Ctx, env *Env) *[]reflect.Value {
+	expr := ctx.Input
 	if e, err := parser.ParseExpr(expr); err != nil {
 		t.Fatalf("Failed to parse expression '%s' (%v)", expr, err)
 	} else if aexpr, errs := CheckExpr(ctx, e, env); errs != nil {
@@ -24,13 +28,34 @@ func getResults(t *testing.T, expr string, env *Env) *[]reflect.Value {
 	return nil
 }
 
+func getCheckedExpr(t *testing.T, expr string, env *Env) Expr {
+	if e, err := parser.ParseExpr(expr); err != nil {
+		t.Fatalf("Failed to parse expression '%s' (%v)", expr, err)
+	} else if aexpr, errs := CheckExpr(&Ctx{Input: expr}, e, env); errs != nil {
+		t.Fatalf("Failed to check expression '%s' (%v)", expr, errs)
+	} else {
+		return aexpr
+	}
+	return nil
+}
+
 func expectResult(t *testing.T, expr string, env *Env, expected interface{}) {
 	expect2 := []interface{}{expected}
 	expectResults(t, expr, env, &expect2)
 }
 
+func expectResultCtx(t *testing.T, ctx *Ctx, env *Env, expected interface{}) {
+	expect2 := []interface{}{expected}
+	expectResultsCtx(t, ctx, env, &expect2)
+}
+
 func expectResults(t *testing.T, expr string, env *Env, expected *[]interface{}) {
-	results := getResults(t, expr, env)
+	expectResultsCtx(t, &Ctx{Input: expr}, env, expected)
+}
+
+func expectResultsCtx(t *testing.T, ctx *Ctx, env *Env, expected *[]interface{}) {
+	expr := ctx.Input
+	results := getResultsCtx(t, ctx, env)
 	if nil == results {
 		if expected != nil {
 			t.Fatalf("Expression '%s' is nil but expected '%+v'", expr, *expected)
@@ -49,7 +74,11 @@ func expectResults(t *testing.T, expr string, env *Env, expected *[]interface{})
 }
 
 func expectPanic(t *testing.T, expr string, env *Env, panicString string) {
-	ctx := &Ctx{expr}
+	expectPanicCtx(t, &Ctx{Input: expr}, env, panicString)
+}
+
+func expectPanicCtx(t *testing.T, ctx *Ctx, env *Env, panicString string) {
+	expr := ctx.Input
 	if e, err := parser.ParseExpr(expr); err != nil {
 		t.Fatalf("Failed to parse expression '%s' (%v)", expr, err)
 	} else if aexpr, errs := CheckExpr(ctx, e, env); errs != nil {
@@ -62,7 +91,7 @@ func expectPanic(t *testing.T, expr string, env *Env, panicString string) {
 }
 
 func expectConst(t *testing.T, expr string, env *Env, expected interface{}, expectedType reflect.Type) {
-	ctx := &Ctx{expr}
+	ctx := &Ctx{Input: expr}
 	if e, err := parser.ParseExpr(expr); err != nil {
 		t.Fatalf("Failed to parse expression '%s' (%v)", expr, err)
 	} else if aexpr, errs := CheckExpr(ctx, e, env); errs != nil {
@@ -72,7 +101,7 @@ func expectConst(t *testing.T, expr string, env *Env, expected interface{}, expe
 	} else if expectedNumber, ok := expected.(*ConstNumber); ok {
 		if actual, ok2 := aexpr.Const().Interface().(*ConstNumber); !ok2 {
 			t.Fatalf("Expression '%s' yielded '%v', expected '%v'", expr, aexpr.Const(), expected)
-		} else if !actual.Value.Equals(&expectedNumber.Value) {
+		} else if !actual.Equals(expectedNumber) {
 			t.Fatalf("Expression '%s' yielded '%v', expected '%v'", expr, actual, expected)
 		} else if len(aexpr.KnownType()) == 0 {
 			t.Fatalf("Expression '%s' expected to have type '%v'", expr, expectedType)
@@ -91,7 +120,7 @@ func expectConst(t *testing.T, expr string, env *Env, expected interface{}, expe
 }
 
 func expectType(t *testing.T, expr string, env *Env, expectedType reflect.Type) {
-	ctx := &Ctx{expr}
+	ctx := &Ctx{Input: expr}
 	if e, err := parser.ParseExpr(expr); err != nil {
 		t.Fatalf("Failed to parse expression '%s' (%v)", expr, err)
 	} else if aexpr, errs := CheckExpr(ctx, e, env); errs != nil {
@@ -105,7 +134,11 @@ func expectType(t *testing.T, expr string, env *Env, expectedType reflect.Type)
 	}
 }
 func expectCheckError(t *testing.T, expr string, env *Env, errorString ...string) {
-	ctx := &Ctx{expr}
+	expectCheckErrorCtx(t, &Ctx{Input: expr}, env, errorString...)
+}
+
+func expectCheckErrorCtx(t *testing.T, ctx *Ctx, env *Env, errorString ...string) {
+	expr := ctx.Input
 	if e, err := parser.ParseExpr(expr); err != nil {
 		t.Fatalf("Failed to parse expression '%s' (%v)", expr, err)
 	} else if _, errs := CheckExpr(ctx, e, env); errs != nil {
@@ -139,18 +172,14 @@ func expectCheckError(t *testing.T, expr string, env *Env, errorString ...string
 }
 
 func typesEqual(expected, actual reflect.Type) bool {
-	var unwrapped reflect.Type
-	switch t := actual.(type) {
-	case Rune:
-		unwrapped = t.Type
-	default:
-		unwrapped = actual
-	}
-	return reflect.DeepEqual(expected, unwrapped)
+	return reflect.DeepEqual(expected, actual)
 }
 
+// An Env for expressions in this package, as the expected results of the
+// generated tests are those of gc compiling them here.
 func makeEnv() *Env {
 	return &Env {
+		Path: "github.com/0xfaded/eval",
 		Vars: make(map[string] reflect.Value),
 		Consts: make(map[string] reflect.Value),
 		Funcs: make(map[string] reflect.Value),
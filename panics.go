@@ -10,6 +10,8 @@ type PanicDivideByZero struct {}
 type PanicInvalidDereference struct {}
 type PanicIndexOutOfBounds struct {}
 type PanicSliceOutOfBounds struct {}
+type PanicCloseOfNilChannel struct {}
+type PanicCloseOfClosedChannel struct {}
 type PanicInterfaceConversion struct {
 	// type of type assert operand
 	xT reflect.Type
@@ -20,12 +22,39 @@ type PanicInterfaceConversion struct {
 	// the dynamic type of operand. nil for interface to interface assertions
 	dynamicT reflect.Type
 }
+type PanicCallDenied struct {
+	callee Callee
+}
 type PanicUncomparableType struct {
 	dynamicT reflect.Type
 }
 type PanicUnhashableType struct {
 	dynamicT reflect.Type
 }
+type PanicAssignToNilMap struct {}
+type PanicSendOnClosedChannel struct {}
+type PanicDeadlock struct {}
+type PanicCanceled struct {
+	err error
+}
+type PanicStackOverflow struct {
+	limit int
+}
+type PanicFuncNotDeclared struct {
+	name string
+}
+
+// A receive outside of an interpreted function which would have blocked,
+// when neither Ctx.DetectDeadlock nor Ctx.Context allow it to wait.
+type PanicWouldBlock struct {}
+
+// A panic raised by a host function called from an interpreted function.
+// It unwinds the interpreted calls, running their deferred calls, and
+// recover returns the value given to panic. If it is not recovered, it is
+// raised again to the host code calling the interpreted function.
+type PanicHost struct {
+	value interface{}
+}
 
 func (p PanicUser) Error() string {
 	return fmt.Sprint(reflect.Value(p).Interface())
@@ -47,6 +76,14 @@ func (err PanicSliceOutOfBounds) Error() string {
         return "runtime error: slice bounds out of range"
 }
 
+func (err PanicCloseOfNilChannel) Error() string {
+	return "close of nil channel"
+}
+
+func (err PanicCloseOfClosedChannel) Error() string {
+	return "close of closed channel"
+}
+
 func (err PanicInterfaceConversion) Error() string {
 	if err.xT == nil {
 		return fmt.Sprintf("interface conversion: nil is not %v", err.aT)
@@ -68,6 +105,10 @@ func (err PanicInterfaceConversion) Error() string {
 	}
 }
 
+func (err PanicCallDenied) Error() string {
+	return fmt.Sprintf("method %v.%s not allowed by policy", err.callee.Recv, err.callee.Name)
+}
+
 func (err PanicUncomparableType) Error() string {
         return fmt.Sprintf("runtime error: comparing uncomparable type %v", err.dynamicT)
 }
@@ -75,3 +116,46 @@ func (err PanicUncomparableType) Error() string {
 func (err PanicUnhashableType) Error() string {
         return fmt.Sprintf("runtime error: hash of unhashable type %v", err.dynamicT)
 }
+
+func (err PanicAssignToNilMap) Error() string {
+	return "assignment to entry in nil map"
+}
+
+func (err PanicSendOnClosedChannel) Error() string {
+	return "send on closed channel"
+}
+
+func (err PanicDeadlock) Error() string {
+	return "all goroutines are asleep - deadlock!"
+}
+
+func (err PanicCanceled) Error() string {
+	return "blocked channel operation abandoned: " + err.err.Error()
+}
+
+// The error of the Ctx.Context, such as context.Canceled
+func (err PanicCanceled) Unwrap() error {
+	return err.err
+}
+
+func (err PanicStackOverflow) Error() string {
+	return fmt.Sprintf("runtime: goroutine stack exceeds %d-call limit", err.limit)
+}
+
+func (err PanicFuncNotDeclared) Error() string {
+	return fmt.Sprintf("%s called before its declaration", err.name)
+}
+
+func (err PanicWouldBlock) Error() string {
+	return "receive would block: nothing was received"
+}
+
+func (err PanicHost) Error() string {
+	return fmt.Sprint(err.value)
+}
+
+// The value given to panic, if it is an error such as a runtime.Error
+func (err PanicHost) Unwrap() error {
+	e, _ := err.value.(error)
+	return e
+}
@@ -0,0 +1,60 @@
+package eval
+
+import (
+	"reflect"
+)
+
+// Describes a function or method to a Policy.
+type Callee struct {
+	// Package of a function selected from Env.Pkgs, as written in the
+	// expression, and its import path taken from Env.Path. Both are empty
+	// for functions of the top level Env and for methods.
+	Pkg     string
+	PkgPath string
+
+	// Name of the function, method or function valued struct field. Empty
+	// for anonymous function values, such as those stored in slices or
+	// returned by other calls.
+	Name string
+
+	// Receiver type of a method, nil for functions. At check time, a method
+	// called through an interface has the interface as its receiver. The
+	// method is checked again against the dynamic type when evaluated, as
+	// is a method promoted from an interface embedded in a struct.
+	Recv reflect.Type
+
+	// Struct type declaring a function valued field called as x.Name(),
+	// nil otherwise.
+	Struct reflect.Type
+
+	// The function, if it is known before evaluation. Only functions from
+	// Env.Funcs are known; methods and function valued variables are not.
+	Func reflect.Value
+}
+
+// A Policy decides which functions and methods an expression may call.
+// Calls which are not allowed are rejected by CheckExpr with an
+// ErrCallDenied, or by evaluation with a PanicCallDenied for methods
+// dispatched through an interface. The func value of a method expression
+// on an interface type, such as io.Reader.Read, checks the method it
+// dispatches to against the Policy of the evaluation calling it. Host code
+// calling it outside of any evaluation gets the Policy it was checked with.
+type Policy interface {
+	Allow(callee Callee) bool
+}
+
+// An adapter allowing ordinary functions to be used as a Policy.
+type PolicyFunc func(callee Callee) bool
+
+func (f PolicyFunc) Allow(callee Callee) bool {
+	return f(callee)
+}
+
+// Check callee against ctx.Policy, returning an ErrCallDenied at node
+// if it is not allowed.
+func checkPolicy(ctx *Ctx, node Expr, callee Callee) []error {
+	if ctx.Policy != nil && !ctx.Policy.Allow(callee) {
+		return []error{ErrCallDenied{at(ctx, node), callee}}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package eval
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go/parser"
+)
+
+// Deny everything named "F", along with anything without a name
+var denyF = PolicyFunc(func(callee Callee) bool {
+	return callee.Name != "F" && callee.Name != ""
+})
+
+func policyCtx(expr string) *Ctx {
+	return &Ctx{Input: expr, Policy: denyF}
+}
+
+func TestPolicyFuncs(t *testing.T) {
+	env := makeEnv()
+	f := func() int { return 1 }
+	env.Funcs["F"] = reflect.ValueOf(f)
+	env.Funcs["G"] = reflect.ValueOf(func(func() int) int { return 2 })
+	env.Vars["fs"] = reflect.ValueOf(&[]func() int{f})
+	pkg := makeEnv()
+	pkg.Path = "strings"
+	pkg.Funcs["F"] = reflect.ValueOf(f)
+	env.Pkgs["p"] = pkg
+
+	expectCheckErrorCtx(t, policyCtx("F()"), env, "F not allowed by policy")
+	expectCheckErrorCtx(t, policyCtx("p.F()"), env, "p.F not allowed by policy")
+	expectCheckErrorCtx(t, policyCtx("G(p.F)"), env, "p.F not allowed by policy")
+	expectCheckErrorCtx(t, policyCtx("fs[0]()"), env, "fs[0] not allowed by policy")
+}
+
+func TestPolicyCallee(t *testing.T) {
+	env := makeEnv()
+	pkg := makeEnv()
+	pkg.Name = "strings"
+	pkg.Path = "strings"
+	pkg.Funcs["ToUpper"] = reflect.ValueOf(strings.ToUpper)
+	env.Pkgs["strings"] = pkg
+
+	var seen []Callee
+	expr := `strings.ToUpper("a")`
+	ctx := &Ctx{Input: expr, Policy: PolicyFunc(func(callee Callee) bool {
+		seen = append(seen, callee)
+		return true
+	})}
+	if e, err := parser.ParseExpr(expr); err != nil {
+		t.Fatalf("Failed to parse expression '%s' (%v)", expr, err)
+	} else if _, errs := CheckExpr(ctx, e, env); errs != nil {
+		t.Fatalf("Failed to check expression '%s' (%v)", expr, errs)
+	} else if len(seen) != 1 {
+		t.Fatalf("Expected policy to be consulted once, not %d times", len(seen))
+	} else if c := seen[0]; c.Pkg != "strings" || c.PkgPath != "strings" ||
+		c.Name != "ToUpper" || c.Recv != nil || !c.Func.IsValid() {
+		t.Fatalf("Unexpected callee %+v", c)
+	}
+}
+
+func TestPolicyMethods(t *testing.T) {
+	env := makeEnv()
+	s := SelStruct{}
+	env.Vars["s"] = reflect.ValueOf(&s)
+
+	denyF := &Ctx{Input: "s.F()", Policy: PolicyFunc(func(callee Callee) bool {
+		return callee.Name != "F" || callee.Recv != reflect.TypeOf(&s)
+	})}
+	expectCheckErrorCtx(t, denyF, env, "s.F not allowed by policy")
+}
+
+func TestPolicyInterfaceDispatch(t *testing.T) {
+	env := makeEnv()
+	var i fmt.Stringer = reflect.TypeOf(0)
+	env.Vars["i"] = reflect.ValueOf(&i)
+
+	denyReflect := PolicyFunc(func(callee Callee) bool {
+		return callee.Recv.Kind() == reflect.Interface
+	})
+	expectPanicCtx(t, &Ctx{Input: "i.String()", Policy: denyReflect}, env,
+		fmt.Sprintf("method %v.String not allowed by policy", reflect.TypeOf(i)))
+}
+
+func TestPolicyInterfaceMethodExpr(t *testing.T) {
+	env := makeEnv()
+	var i, n SelInterface = new(SelInt), nil
+	env.Vars["i"] = reflect.ValueOf(&i)
+	env.Vars["n"] = reflect.ValueOf(&n)
+	env.Types["SelInterface"] = reflect.TypeOf(&i).Elem()
+
+	denySelInt := PolicyFunc(func(callee Callee) bool {
+		return callee.Recv != reflect.TypeOf(new(SelInt))
+	})
+	expectPanicCtx(t, &Ctx{Input: "SelInterface.F(i)", Policy: denySelInt}, env,
+		"method *eval.SelInt.F not allowed by policy")
+	expectPanic(t, "SelInterface.F(n)", env,
+		"runtime error: invalid memory address or nil pointer dereference")
+
+	// A kept func value follows the policy of the evaluation calling it
+	for _, policy := range []Policy{nil, denySelInt} {
+		ctx := &Ctx{Input: "SelInterface.F", Policy: policy}
+		e, _ := parser.ParseExpr(ctx.Input)
+		cexpr, errs := CheckExpr(ctx, e, env)
+		if errs != nil {
+			t.Fatalf("Failed to check %s (%v)", ctx.Input, errs)
+		}
+		f, _, err := EvalExpr(ctx, cexpr, env)
+		if err != nil {
+			t.Fatalf("Failed to evaluate %s (%v)", ctx.Input, err)
+		}
+		env.Vars["f"] = reflect.New((*f)[0].Type())
+		env.Vars["f"].Elem().Set((*f)[0])
+		expectPanicCtx(t, &Ctx{Input: "f(i)", Policy: denySelInt}, env,
+			"method *eval.SelInt.F not allowed by policy")
+		expectResult(t, "f(i)", env, 2)
+	}
+}
+
+type PolicyFuncs struct {
+	F func() int
+}
+
+type PolicyOuter struct {
+	A int
+	PolicyFuncs
+}
+
+func TestPolicyFieldCallee(t *testing.T) {
+	env := makeEnv()
+	o := PolicyOuter{PolicyFuncs: PolicyFuncs{func() int { return 1 }}}
+	env.Vars["o"] = reflect.ValueOf(&o)
+
+	var seen []Callee
+	expr := "o.F()"
+	ctx := &Ctx{Input: expr, Policy: PolicyFunc(func(callee Callee) bool {
+		seen = append(seen, callee)
+		return true
+	})}
+	if e, err := parser.ParseExpr(expr); err != nil {
+		t.Fatalf("Failed to parse expression '%s' (%v)", expr, err)
+	} else if _, errs := CheckExpr(ctx, e, env); errs != nil {
+		t.Fatalf("Failed to check expression '%s' (%v)", expr, errs)
+	} else if len(seen) != 1 {
+		t.Fatalf("Expected policy to be consulted once, not %d times", len(seen))
+	} else if c := seen[0]; c.Name != "F" || c.Struct != reflect.TypeOf(o.PolicyFuncs) ||
+		c.Recv != nil {
+		t.Fatalf("Unexpected callee %+v", c)
+	}
+	expectCheckErrorCtx(t, policyCtx("o.F()"), env, "o.F not allowed by policy")
+}
+
+type PolicyEmbed struct {
+	SelInterface
+}
+
+type PolicyEmbedP struct {
+	*PolicyEmbed
+}
+
+func TestPolicyPromotedInterfaceMethod(t *testing.T) {
+	env := makeEnv()
+	e := PolicyEmbed{new(SelInt)}
+	p := PolicyEmbedP{&PolicyEmbed{new(SelInt)}}
+	env.Vars["e"] = reflect.ValueOf(&e)
+	env.Vars["p"] = reflect.ValueOf(&p)
+
+	denySelInt := PolicyFunc(func(callee Callee) bool {
+		return callee.Recv != reflect.TypeOf(new(SelInt))
+	})
+	expectPanicCtx(t, &Ctx{Input: "e.F()", Policy: denySelInt}, env,
+		"method *eval.SelInt.F not allowed by policy")
+	expectPanicCtx(t, &Ctx{Input: "p.E()", Policy: denySelInt}, env,
+		"method *eval.SelInt.E not allowed by policy")
+
+	e.SelInterface = &SelStruct{}
+	expectResultCtx(t, &Ctx{Input: "e.E()", Policy: denySelInt}, env, 1)
+}
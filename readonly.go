@@ -0,0 +1,50 @@
+package eval
+
+import (
+	"reflect"
+)
+
+// Name of the function called by fun, the checked Fun of a CallExpr, in
+// the form used by Ctx.PureFuncs. Returns "" if the function has no
+// name, for example if it is the result of another call.
+func funcName(fun Expr) string {
+	switch fun := skipSuperfluousParens(fun).(type) {
+	case *Ident:
+		if fun.source == envMethod {
+			return methodName(fun.method.Type.In(0), fun.Name)
+		}
+		return fun.Name
+	case *SelectorExpr:
+		if fun.pkgName != "" {
+			return fun.pkgName + "." + fun.Sel.Name
+		} else if fun.methodExpr.IsValid() {
+			return methodName(fun.knownType[0].In(0), fun.Sel.Name)
+		} else if fun.field == nil {
+			t := fun.X.(Expr).KnownType()[0]
+			if fun.isPtrReceiver {
+				t = reflect.PtrTo(t)
+			}
+			return methodName(t, fun.Sel.Name)
+		}
+	}
+	return ""
+}
+
+// Name of the method called name of recv, spelt with the receiver type
+// which declares it. Methods with value receivers are named after T even
+// when called through a *T, so that one key matches both.
+func methodName(recv reflect.Type, name string) string {
+	if recv.Kind() == reflect.Ptr {
+		if _, ok := recv.Elem().MethodByName(name); ok {
+			recv = recv.Elem()
+		}
+	}
+	return recv.String() + "." + name
+}
+
+// Is fun, the checked Fun of a CallExpr, allowed to be called in
+// ReadOnly mode.
+func (ctx *Ctx) isPure(fun Expr) bool {
+	name := funcName(fun)
+	return name != "" && ctx.PureFuncs[name]
+}
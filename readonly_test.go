@@ -0,0 +1,101 @@
+package eval
+
+import (
+	"reflect"
+	"testing"
+
+	"go/parser"
+)
+
+func readOnlyCtx(expr string, pure ...string) *Ctx {
+	ctx := &Ctx{Input: expr, ReadOnly: true, PureFuncs: make(map[string]bool)}
+	for _, name := range pure {
+		ctx.PureFuncs[name] = true
+	}
+	return ctx
+}
+
+func expectReadOnly(t *testing.T, expr string, env *Env, pure ...string) {
+	ctx := readOnlyCtx(expr, pure...)
+	if e, err := parser.ParseExpr(expr); err != nil {
+		t.Fatalf("Failed to parse expression '%s' (%v)", expr, err)
+	} else if _, errs := CheckExpr(ctx, e, env); errs != nil {
+		t.Fatalf("Unexpected check errors for read-only expression '%s' (%v)", expr, errs)
+	}
+}
+
+func TestReadOnlyCall(t *testing.T) {
+	env := makeEnv()
+	env.Funcs["f"] = reflect.ValueOf(func() int { return 1 })
+	s := SelStruct{}
+	env.Vars["s"] = reflect.ValueOf(&s)
+	pkg := makeEnv()
+	pkg.Funcs["F"] = reflect.ValueOf(func() int { return 1 })
+	env.Pkgs["p"] = pkg
+
+	expectCheckErrorCtx(t, readOnlyCtx("f()"), env,
+		"function call not allowed in read-only mode: f()")
+	expectCheckErrorCtx(t, readOnlyCtx("p.F()"), env,
+		"function call not allowed in read-only mode: p.F()")
+	expectCheckErrorCtx(t, readOnlyCtx("s.E()", "f"), env,
+		"method call not allowed in read-only mode: s.E()")
+
+	expectReadOnly(t, "f() + 1", env, "f")
+	expectReadOnly(t, "p.F()", env, "p.F")
+	expectReadOnly(t, "s.E()", env, "eval.SelStruct.E")
+	expectReadOnly(t, "s.F()", env, "*eval.SelStruct.F")
+}
+
+func TestReadOnlyMethodNames(t *testing.T) {
+	env := makeEnv()
+	s := SelStruct{}
+	p := &s
+	env.Vars["s"] = reflect.ValueOf(&s)
+	env.Vars["p"] = reflect.ValueOf(&p)
+	env.Types["SelStruct"] = reflect.TypeOf(s)
+
+	// Value methods are keyed by T, however they are called
+	expectReadOnly(t, "p.E() + s.E()", env, "eval.SelStruct.E")
+	expectReadOnly(t, "SelStruct.E(s) + (*SelStruct).E(p)", env, "eval.SelStruct.E")
+	expectReadOnly(t, "p.F() + s.F()", env, "*eval.SelStruct.F")
+	expectCheckErrorCtx(t, readOnlyCtx("p.E()", "*eval.SelStruct.E"), env,
+		"method call not allowed in read-only mode: p.E()")
+
+	recv, _ := WithReceiver(makeEnv(), &RecvPerson{})
+	expectReadOnly(t, "Adult()", recv, "eval.RecvPerson.Adult")
+	expectReadOnly(t, "Birthday()", recv, "*eval.RecvPerson.Birthday")
+}
+
+func TestReadOnlyBuiltins(t *testing.T) {
+	env := makeEnv()
+	a, m := []int{1, 2}, map[int]int{}
+	env.Vars["a"] = reflect.ValueOf(&a)
+	env.Vars["m"] = reflect.ValueOf(&m)
+
+	expectCheckErrorCtx(t, readOnlyCtx("append(a, 1)"), env,
+		"append not allowed in read-only mode: append(a, 1)")
+	expectCheckErrorCtx(t, readOnlyCtx("copy(a, a)"), env,
+		"copy not allowed in read-only mode: copy(a, a)")
+	expectCheckErrorCtx(t, readOnlyCtx("delete(m, 1)"), env,
+		"delete not allowed in read-only mode: delete(m, 1)")
+
+	expectReadOnly(t, "append([]int{}, a...)", env)
+	expectReadOnly(t, "len(a) + cap(a) + len(m)", env)
+}
+
+func TestReadOnlyUnary(t *testing.T) {
+	env := makeEnv()
+	c, x := make(chan int), 1
+	env.Vars["c"] = reflect.ValueOf(&c)
+	env.Vars["x"] = reflect.ValueOf(&x)
+
+	expectCheckErrorCtx(t, readOnlyCtx("<-c"), env,
+		"channel receive not allowed in read-only mode: <-c")
+	expectCheckErrorCtx(t, readOnlyCtx("&x"), env,
+		"taking the address not allowed in read-only mode: &x")
+	expectCheckErrorCtx(t, readOnlyCtx("&x != nil"), env,
+		"taking the address not allowed in read-only mode: &x")
+
+	expectReadOnly(t, "&[]int{1}", env)
+	expectReadOnly(t, "-x", env)
+}
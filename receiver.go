@@ -0,0 +1,50 @@
+package eval
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// WithReceiver creates a scope nested in env in which bare identifiers
+// resolve to the fields and methods of recv, including promoted ones, as
+// they would in the body of a method of recv. Names not found on recv
+// are looked up in env. This allows rules such as
+//
+//  Age >= 18 && Country == "NZ"
+//
+// to be evaluated directly against a struct. If recv is a pointer, its
+// fields are aliased, otherwise they belong to a copy of recv. In both
+// cases methods with pointer receivers may be called.
+func WithReceiver(env *Env, recv interface{}) (*Env, error) {
+	v := reflect.ValueOf(recv)
+	if !v.IsValid() {
+		return nil, fmt.Errorf("eval: WithReceiver: receiver is nil")
+	} else if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, fmt.Errorf("eval: WithReceiver: receiver is a nil %T", recv)
+		}
+	} else {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		v = ptr
+	}
+	scope := NewScope(env)
+	scope.receiver = v
+	return scope, nil
+}
+
+// Find the field or method called name on the receiver of env. If found,
+// either field.Index is non-nil or method.Func is valid.
+func (env *Env) lookupReceiver(name string) (field reflect.StructField, method reflect.Method, ok bool) {
+	if !env.receiver.IsValid() {
+		return
+	}
+	t := env.receiver.Type()
+	if t.Elem().Kind() == reflect.Struct {
+		if field, ok = t.Elem().FieldByName(name); ok {
+			return
+		}
+	}
+	method, ok = t.MethodByName(name)
+	return
+}
@@ -0,0 +1,71 @@
+package eval
+
+import (
+	"reflect"
+	"testing"
+)
+
+type RecvPerson struct {
+	Age     int
+	Country string
+	SelNested
+}
+
+func (p RecvPerson) Adult() bool {
+	return p.Age >= 18
+}
+
+func (p *RecvPerson) Birthday() int {
+	p.Age += 1
+	return p.Age
+}
+
+func TestReceiverFields(t *testing.T) {
+	p := &RecvPerson{Age: 20, Country: "NZ", SelNested: SelNested{D: 4}}
+	env, err := WithReceiver(makeEnv(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, `Age >= 18 && Country == "NZ"`, env, true)
+	expectResult(t, "D", env, 4)
+	expectResult(t, "*&Age", env, 20)
+
+	p.Age = 17
+	expectResult(t, "Age >= 18", env, false)
+}
+
+func TestReceiverMethods(t *testing.T) {
+	p := RecvPerson{Age: 20}
+	env, err := WithReceiver(makeEnv(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectResult(t, "Adult()", env, true)
+	expectResult(t, "Birthday()", env, 21)
+	expectResult(t, "Age", env, 21)
+	if p.Age != 20 {
+		t.Fatalf("A receiver passed by value should be copied")
+	}
+}
+
+func TestReceiverShadowing(t *testing.T) {
+	global := makeEnv()
+	age, other := 99, 1
+	global.Vars["Age"] = reflect.ValueOf(&age)
+	global.Vars["other"] = reflect.ValueOf(&other)
+	env, _ := WithReceiver(global, &RecvPerson{Age: 20})
+	expectResult(t, "Age + other", env, 21)
+
+	local := NewScope(env)
+	local.Vars["Age"] = reflect.ValueOf(&other)
+	expectResult(t, "Age", local, 1)
+}
+
+func TestReceiverErrors(t *testing.T) {
+	if _, err := WithReceiver(makeEnv(), nil); err == nil {
+		t.Fatalf("Expected error for nil receiver")
+	}
+	if _, err := WithReceiver(makeEnv(), (*RecvPerson)(nil)); err == nil {
+		t.Fatalf("Expected error for nil pointer receiver")
+	}
+}
@@ -0,0 +1,93 @@
+package repl
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/0xfaded/eval"
+)
+
+// EnvCompleter returns a Completer for the identifiers in env. A word
+// of the form pkg.Prefix is completed from the members of package pkg.
+func EnvCompleter(env *eval.Env) Completer {
+	return func(line string, pos int) (string, []string, string) {
+		runes := []rune(line)
+		start := pos
+		for start > 0 && isIdentRune(runes[start-1]) {
+			start -= 1
+		}
+		head, word, tail := string(runes[:start]), string(runes[start:pos]), string(runes[pos:])
+
+		scope := env
+		if dot := strings.LastIndex(word, "."); dot >= 0 {
+			pkg, ok := lookupPkg(env, word[:dot])
+			if !ok {
+				return head, nil, tail
+			}
+			scope = pkg
+			head += word[:dot+1]
+			word = word[dot+1:]
+		}
+
+		var completions []string
+		for _, name := range envNames(scope) {
+			if strings.HasPrefix(name, word) {
+				completions = append(completions, name)
+			}
+		}
+		return head, completions, tail
+	}
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// lookupPkg finds the package called name in env or its parents. As in
+// evaluation, a value or type of the same name in an inner scope shadows
+// the package.
+func lookupPkg(env *eval.Env, name string) (eval.Pkg, bool) {
+	for ; env != nil; env = env.Parent {
+		if _, ok := env.Vars[name]; ok {
+			return nil, false
+		} else if _, ok := env.Consts[name]; ok {
+			return nil, false
+		} else if _, ok := env.Funcs[name]; ok {
+			return nil, false
+		} else if _, ok := env.Types[name]; ok {
+			return nil, false
+		} else if pkg, ok := env.Pkgs[name]; ok {
+			return pkg, true
+		}
+	}
+	return nil, false
+}
+
+// envNames returns the sorted names defined in env and its parents.
+func envNames(env *eval.Env) []string {
+	seen := make(map[string]bool)
+	for ; env != nil; env = env.Parent {
+		for name := range env.Vars {
+			seen[name] = true
+		}
+		for name := range env.Consts {
+			seen[name] = true
+		}
+		for name := range env.Funcs {
+			seen[name] = true
+		}
+		for name := range env.Types {
+			seen[name] = true
+		}
+		for name := range env.Pkgs {
+			seen[name] = true
+		}
+	}
+	var names []string
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
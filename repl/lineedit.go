@@ -0,0 +1,483 @@
+// Package repl holds pieces for building an interactive front-end to
+// the evaluator.
+//
+// LineEditor is a small line editor written in pure Go. It does no
+// terminal setup of its own and talks to the terminal only through an
+// io.Reader and an io.Writer, so it works equally well on a real
+// terminal, a pseudo-terminal under a debugger, or a pair of buffers in
+// a test. The caller is responsible for switching the terminal out of
+// canonical (line buffered) mode and turning off echo before calling
+// ReadLine.
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// ErrInterrupted is returned by ReadLine when Ctrl-C is typed.
+var ErrInterrupted = errors.New("repl: interrupted")
+
+// Completer is called when Tab is pressed. It is given the line and
+// the cursor position in runes, and returns the text before the word
+// being completed, the candidate replacements for that word, and the
+// text after it.
+type Completer func(line string, pos int) (head string, completions []string, tail string)
+
+// Key codes understood by the editor.
+const (
+	ctrlA     = 1
+	ctrlB     = 2
+	ctrlC     = 3
+	ctrlD     = 4
+	ctrlE     = 5
+	ctrlF     = 6
+	ctrlG     = 7
+	backspace = 8
+	tab       = 9
+	newline   = 10
+	ctrlK     = 11
+	ctrlL     = 12
+	enter     = 13
+	ctrlN     = 14
+	ctrlP     = 16
+	ctrlR     = 18
+	ctrlU     = 21
+	ctrlW     = 23
+	esc       = 27
+	del       = 127
+)
+
+// Keys that arrive as escape sequences are mapped onto runes in the
+// Unicode private use area so that they can be handled alongside
+// ordinary keys.
+const (
+	keyUp rune = 0xe000 + iota
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyDelete
+	keyUnknown
+)
+
+// LineEditor reads lines from a terminal with emacs style editing,
+// history navigated with the arrow keys, Ctrl-R reverse search and Tab
+// completion.
+type LineEditor struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// Complete, if not nil, is consulted when Tab is pressed.
+	Complete Completer
+
+	// HistoryLimit is the maximum number of history entries kept.
+	// Zero means no limit.
+	HistoryLimit int
+
+	history []string
+
+	// a key read ahead of time by reverse search
+	pending rune
+}
+
+// NewLineEditor returns a LineEditor reading keys from in and echoing
+// to out.
+func NewLineEditor(in io.Reader, out io.Writer) *LineEditor {
+	return &LineEditor{
+		in:           bufio.NewReader(in),
+		out:          out,
+		HistoryLimit: 1000,
+	}
+}
+
+// History returns the history entries, oldest first.
+func (ed *LineEditor) History() []string {
+	return append([]string(nil), ed.history...)
+}
+
+// AddHistory appends line to the history. Empty lines and lines
+// repeating the most recent entry are dropped.
+func (ed *LineEditor) AddHistory(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if n := len(ed.history); n > 0 && ed.history[n-1] == line {
+		return
+	}
+	ed.history = append(ed.history, line)
+	if ed.HistoryLimit > 0 && len(ed.history) > ed.HistoryLimit {
+		ed.history = ed.history[len(ed.history)-ed.HistoryLimit:]
+	}
+}
+
+// ReadHistory appends the lines of r to the history.
+func (ed *LineEditor) ReadHistory(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		ed.AddHistory(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// WriteHistory writes the history to w, one entry per line.
+func (ed *LineEditor) WriteHistory(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, line := range ed.history {
+		if _, err := fmt.Fprintln(bw, line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// LoadHistory reads history from the file at path. A missing file is
+// not an error, so that a first session starts with empty history.
+func (ed *LineEditor) LoadHistory(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	return ed.ReadHistory(f)
+}
+
+// SaveHistory writes the history to the file at path, replacing its
+// previous contents.
+func (ed *LineEditor) SaveHistory(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := ed.WriteHistory(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// lineState is the line being edited by a single ReadLine call.
+type lineState struct {
+	prompt string
+	buf    []rune
+	pos    int
+
+	// position in history, len(history) when editing a new line
+	histPos int
+	// the new line, kept while browsing history
+	saved []rune
+
+	// Set by a Tab which listed rather than inserted completions
+	listed bool
+}
+
+// ReadLine shows prompt and reads a line of input. The returned line
+// does not include the terminating newline. Non-empty lines are added
+// to the history. Ctrl-D on an empty line returns io.EOF and Ctrl-C
+// returns ErrInterrupted.
+func (ed *LineEditor) ReadLine(prompt string) (string, error) {
+	s := &lineState{prompt: prompt, histPos: len(ed.history)}
+	ed.refresh(s)
+	for {
+		r, err := ed.readKey()
+		if err != nil {
+			if err == io.EOF && len(s.buf) != 0 {
+				// Treat a final unterminated line as complete
+				break
+			}
+			return "", err
+		}
+		if r != tab {
+			s.listed = false
+		}
+		switch r {
+		case enter, newline:
+			ed.finish(s)
+			line := string(s.buf)
+			ed.AddHistory(line)
+			return line, nil
+		case ctrlC:
+			ed.finish(s)
+			return "", ErrInterrupted
+		case ctrlD:
+			if len(s.buf) == 0 {
+				ed.finish(s)
+				return "", io.EOF
+			}
+			s.deleteRune()
+		case ctrlA, keyHome:
+			s.pos = 0
+		case ctrlE, keyEnd:
+			s.pos = len(s.buf)
+		case ctrlB, keyLeft:
+			if s.pos > 0 {
+				s.pos -= 1
+			}
+		case ctrlF, keyRight:
+			if s.pos < len(s.buf) {
+				s.pos += 1
+			}
+		case ctrlP, keyUp:
+			ed.historyMove(s, -1)
+		case ctrlN, keyDown:
+			ed.historyMove(s, 1)
+		case backspace, del:
+			if s.pos > 0 {
+				s.pos -= 1
+				s.deleteRune()
+			}
+		case keyDelete:
+			s.deleteRune()
+		case ctrlK:
+			s.buf = s.buf[:s.pos]
+		case ctrlU:
+			s.buf = append([]rune(nil), s.buf[s.pos:]...)
+			s.pos = 0
+		case ctrlW:
+			start := s.pos
+			for start > 0 && unicode.IsSpace(s.buf[start-1]) {
+				start -= 1
+			}
+			for start > 0 && !unicode.IsSpace(s.buf[start-1]) {
+				start -= 1
+			}
+			s.buf = append(s.buf[:start], s.buf[s.pos:]...)
+			s.pos = start
+		case ctrlL:
+			fmt.Fprint(ed.out, "\x1b[H\x1b[2J")
+		case ctrlR:
+			if err := ed.reverseSearch(s); err != nil {
+				return "", err
+			}
+		case tab:
+			ed.complete(s)
+		default:
+			if unicode.IsPrint(r) {
+				s.insert(r)
+			}
+		}
+		ed.refresh(s)
+	}
+	ed.finish(s)
+	line := string(s.buf)
+	ed.AddHistory(line)
+	return line, nil
+}
+
+func (s *lineState) insert(r ...rune) {
+	tail := append(r, s.buf[s.pos:]...)
+	s.buf = append(s.buf[:s.pos], tail...)
+	s.pos += len(r)
+}
+
+func (s *lineState) deleteRune() {
+	if s.pos < len(s.buf) {
+		s.buf = append(s.buf[:s.pos], s.buf[s.pos+1:]...)
+	}
+}
+
+// historyMove replaces the line with the history entry dir steps away.
+func (ed *LineEditor) historyMove(s *lineState, dir int) {
+	to := s.histPos + dir
+	if to < 0 || to > len(ed.history) {
+		return
+	}
+	if s.histPos == len(ed.history) {
+		s.saved = s.buf
+	}
+	s.histPos = to
+	if to == len(ed.history) {
+		s.buf = s.saved
+	} else {
+		s.buf = []rune(ed.history[to])
+	}
+	s.pos = len(s.buf)
+}
+
+// reverseSearch implements Ctrl-R. Typed characters extend the query,
+// Ctrl-R moves to the next older match, Enter or any editing key
+// accepts the match and Ctrl-G restores the original line.
+func (ed *LineEditor) reverseSearch(s *lineState) error {
+	origBuf, origPos := s.buf, s.pos
+	var query []rune
+	from := len(ed.history) - 1
+	match := -1
+
+	search := func(start int) {
+		for i := start; i >= 0; i -= 1 {
+			if at := strings.Index(ed.history[i], string(query)); at >= 0 {
+				match = i
+				s.buf = []rune(ed.history[i])
+				s.pos = len([]rune(ed.history[i][:at]))
+				return
+			}
+		}
+	}
+
+	for {
+		status := "reverse-i-search"
+		if match < 0 && len(query) != 0 {
+			status = "failing reverse-i-search"
+		}
+		fmt.Fprintf(ed.out, "\r(%s)`%s': %s\x1b[K", status, string(query), string(s.buf))
+
+		r, err := ed.readKey()
+		if err != nil {
+			return err
+		}
+		switch r {
+		case ctrlR:
+			if match > 0 {
+				search(match - 1)
+			}
+		case ctrlG:
+			s.buf, s.pos = origBuf, origPos
+			return nil
+		case backspace, del:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				match = -1
+				search(from)
+			}
+		default:
+			if unicode.IsPrint(r) {
+				query = append(query, r)
+				start := from
+				if match >= 0 {
+					start = match
+				}
+				match = -1
+				search(start)
+			} else {
+				// Accept the match and let the key act on it
+				if match >= 0 {
+					if s.histPos == len(ed.history) {
+						s.saved = origBuf
+					}
+					s.histPos = match
+				}
+				ed.pending = r
+				return nil
+			}
+		}
+	}
+}
+
+// complete implements Tab. A single candidate, or the common prefix of
+// several candidates, is inserted. A second Tab with nothing more to
+// insert lists the candidates.
+func (ed *LineEditor) complete(s *lineState) {
+	if ed.Complete == nil {
+		return
+	}
+	head, completions, tail := ed.Complete(string(s.buf), s.pos)
+	if len(completions) == 0 {
+		return
+	}
+	start := len([]rune(head))
+	if start > s.pos {
+		return
+	}
+	word := s.buf[start:s.pos]
+	// Trim whole runes so that the prefix stays valid UTF-8
+	prefix := []rune(completions[0])
+	for _, c := range completions[1:] {
+		for !strings.HasPrefix(c, string(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(completions) == 1 || len(prefix) > len(word) {
+		s.buf = []rune(head + string(prefix) + tail)
+		s.pos = start + len(prefix)
+		return
+	}
+	if s.listed {
+		return
+	}
+	s.listed = true
+	fmt.Fprint(ed.out, "\r\n")
+	fmt.Fprint(ed.out, strings.Join(completions, "  "))
+	fmt.Fprint(ed.out, "\r\n")
+}
+
+// refresh redraws the prompt and line and places the cursor.
+func (ed *LineEditor) refresh(s *lineState) {
+	fmt.Fprintf(ed.out, "\r%s%s\x1b[K", s.prompt, string(s.buf))
+	if back := len(s.buf) - s.pos; back > 0 {
+		fmt.Fprintf(ed.out, "\x1b[%dD", back)
+	}
+}
+
+// finish redraws the whole line and moves to the next one.
+func (ed *LineEditor) finish(s *lineState) {
+	s.pos = len(s.buf)
+	ed.refresh(s)
+	fmt.Fprint(ed.out, "\r\n")
+}
+
+// readKey reads a key, decoding escape sequences for the arrow, Home,
+// End and Delete keys. A terminal writes an escape sequence in one go,
+// so an Esc with nothing buffered after it is returned as a lone Esc
+// rather than waiting for the next key.
+func (ed *LineEditor) readKey() (rune, error) {
+	if ed.pending != 0 {
+		r := ed.pending
+		ed.pending = 0
+		return r, nil
+	}
+	r, _, err := ed.in.ReadRune()
+	if err != nil || r != esc || ed.in.Buffered() == 0 {
+		return r, err
+	}
+	r, _, err = ed.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if r != '[' && r != 'O' {
+		// Alt-key, which is not bound
+		return keyUnknown, nil
+	}
+	var param []rune
+	for {
+		r, _, err = ed.in.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if r < '0' || r > '9' && r != ';' {
+			break
+		}
+		param = append(param, r)
+	}
+	switch r {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	case 'H':
+		return keyHome, nil
+	case 'F':
+		return keyEnd, nil
+	case '~':
+		switch string(param) {
+		case "1", "7":
+			return keyHome, nil
+		case "4", "8":
+			return keyEnd, nil
+		case "3":
+			return keyDelete, nil
+		}
+	}
+	return keyUnknown, nil
+}
@@ -0,0 +1,167 @@
+package repl
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/0xfaded/eval"
+)
+
+func expectLines(t *testing.T, ed *LineEditor, expected ...string) {
+	for i, e := range expected {
+		if line, err := ed.ReadLine("> "); err != nil {
+			t.Fatalf("Line %d: unexpected error %v", i, err)
+		} else if line != e {
+			t.Fatalf("Line %d: read `%s`, expected `%s`", i, line, e)
+		}
+	}
+}
+
+func makeEnv() *eval.Env {
+	return &eval.Env{
+		Vars:   make(map[string]reflect.Value),
+		Consts: make(map[string]reflect.Value),
+		Funcs:  make(map[string]reflect.Value),
+		Types:  make(map[string]reflect.Type),
+		Pkgs:   make(map[string]eval.Pkg),
+	}
+}
+
+func newEditor(keys string) *LineEditor {
+	return NewLineEditor(strings.NewReader(keys), new(bytes.Buffer))
+}
+
+func TestLineEditorEditing(t *testing.T) {
+	ed := newEditor("abc\r" +
+		"bc\x01a\x05d\r" + // Ctrl-A, Ctrl-E
+		"ac\x1b[Db\r" + // left arrow
+		"abXc\x1b[D\x7f\r" + // backspace
+		"abXc\x1b[D\x1b[D\x1b[3~\r" + // delete
+		"foo bar\x17baz\r" + // Ctrl-W
+		"abcdef\x02\x02\x0b\r" + // Ctrl-B, Ctrl-K
+		"abcdef\x02\x02\x15\r") // Ctrl-U
+	expectLines(t, ed, "abc", "abcd", "abc", "abc", "abc", "foo baz", "abcd", "ef")
+}
+
+func TestLineEditorEOF(t *testing.T) {
+	ed := newEditor("x\x04\x04")
+	if _, err := ed.ReadLine("> "); err != nil {
+		t.Fatalf("Ctrl-D on non-empty line should delete, not %v", err)
+	}
+	ed = newEditor("\x04")
+	if _, err := ed.ReadLine("> "); err != io.EOF {
+		t.Fatalf("Expected io.EOF, got %v", err)
+	}
+	ed = newEditor("abc\x03")
+	if _, err := ed.ReadLine("> "); err != ErrInterrupted {
+		t.Fatalf("Expected ErrInterrupted, got %v", err)
+	}
+}
+
+func TestLineEditorHistory(t *testing.T) {
+	ed := newEditor("one\rtwo\rtwo\r\x1b[A\x1b[A\r\x1b[A\x1b[A\x1b[B\r\x10x\r")
+	expectLines(t, ed, "one", "two", "two", "one", "one", "onex")
+	expected := []string{"one", "two", "one", "onex"}
+	if h := ed.History(); !reflect.DeepEqual(h, expected) {
+		t.Fatalf("History %v, expected %v", h, expected)
+	}
+}
+
+func TestLineEditorReverseSearch(t *testing.T) {
+	ed := newEditor("len(a)\rfoo(b)\rlen(c)\r" +
+		"\x12le\r" + // most recent match
+		"\x12le\x12\r" + // older match
+		"\x12fo\x05!\r" + // accept with Ctrl-E, then edit
+		"x\x12zzz\x07\r") // failed search, cancelled
+	expectLines(t, ed, "len(a)", "foo(b)", "len(c)", "len(c)", "len(a)", "foo(b)!", "x")
+}
+
+func TestLineEditorReverseSearchKeepsLine(t *testing.T) {
+	ed := newEditor("len(a)\rfoo(b)\r" +
+		"draft\x12le\x1b[B\x1b[B\r") // accept, then back down to the new line
+	expectLines(t, ed, "len(a)", "foo(b)", "draft")
+}
+
+// chunkReader returns one chunk per Read, as a terminal delivers the
+// bytes of each key press.
+type chunkReader []string
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if len(*c) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, (*c)[0])
+	(*c)[0] = (*c)[0][n:]
+	if (*c)[0] == "" {
+		*c = (*c)[1:]
+	}
+	return n, nil
+}
+
+func TestLineEditorLoneEsc(t *testing.T) {
+	keys := chunkReader{"ab\x1b", "c", "\x1b[D", "\x1b[D", "x\r"}
+	ed := NewLineEditor(&keys, new(bytes.Buffer))
+	expectLines(t, ed, "axbc")
+}
+
+func TestLineEditorPersistHistory(t *testing.T) {
+	ed := newEditor("a\rb\r")
+	expectLines(t, ed, "a", "b")
+	var saved bytes.Buffer
+	if err := ed.WriteHistory(&saved); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newEditor("\x1b[A\x1b[A\r")
+	if err := restored.ReadHistory(&saved); err != nil {
+		t.Fatal(err)
+	}
+	expectLines(t, restored, "a")
+}
+
+func TestLineEditorComplete(t *testing.T) {
+	env := makeEnv()
+	x := 1
+	env.Vars["alpha"] = reflect.ValueOf(&x)
+	env.Vars["alpine"] = reflect.ValueOf(&x)
+	env.Vars["beta"] = reflect.ValueOf(&x)
+	pkg := makeEnv()
+	pkg.Funcs["Println"] = reflect.ValueOf(func() {})
+	env.Pkgs["fmt"] = pkg
+
+	ed := newEditor("b\t\ral\t\tha\rfmt.P\t()\r")
+	ed.Complete = EnvCompleter(env)
+	expectLines(t, ed, "beta", "alpha", "fmt.Println()")
+}
+
+func TestLineEditorCompleteShadowedPkg(t *testing.T) {
+	global := makeEnv()
+	pkg := makeEnv()
+	pkg.Funcs["Println"] = reflect.ValueOf(func() {})
+	global.Pkgs["fmt"] = pkg
+	local := eval.NewScope(global)
+	x := 1
+	local.Vars["fmt"] = reflect.ValueOf(&x)
+
+	ed := newEditor("fmt.P\t\r")
+	ed.Complete = EnvCompleter(local)
+	expectLines(t, ed, "fmt.P")
+
+	ed = newEditor("fmt.P\t\r")
+	ed.Complete = EnvCompleter(global)
+	expectLines(t, ed, "fmt.Println")
+}
+
+func TestLineEditorCompleteUnicode(t *testing.T) {
+	env := makeEnv()
+	x := 1
+	env.Vars["größe"] = reflect.ValueOf(&x)
+	env.Vars["grün"] = reflect.ValueOf(&x)
+
+	ed := newEditor("g\t\r")
+	ed.Complete = EnvCompleter(env)
+	expectLines(t, ed, "gr")
+}
@@ -0,0 +1,337 @@
+package eval
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"go/constant"
+	"go/parser"
+	"go/token"
+)
+
+// The on-disk form of an Env, written by SaveEnv
+type snapshot struct {
+	Vars   []snapshotEntry
+	Consts []snapshotEntry
+}
+
+// A single variable or constant. Type is a Go type expression, resolved
+// in the Env being restored, or "untyped" for untyped numeric constants.
+// Data holds the gob encoding of the value.
+type snapshotEntry struct {
+	Name string
+	Type string
+	Data []byte
+}
+
+// Reports a variable or constant which SaveEnv or LoadEnv skipped.
+type ErrSnapshotEntry struct {
+	Name string
+	Err  error
+}
+
+func (err ErrSnapshotEntry) Error() string {
+	return fmt.Sprintf("%s: %v", err.Name, err.Err)
+}
+
+// SaveEnv writes the variables and constants bound directly in env to w,
+// so that they may be restored by LoadEnv in another process. Funcs, Types,
+// Pkgs and Parent scopes are code rather than state, and are not saved.
+//
+// Types are written by name, so a value can only be saved if its type is
+// predeclared, registered in env.Types or the Types of one of env.Pkgs,
+// or is a slice, array, map or pointer built from such types. Values are
+// encoded with encoding/gob. Entries which cannot be saved, such as funcs,
+// chans, unsafe pointers and structs with unexported, func or chan fields,
+// are left out of the snapshot and reported in skipped as ErrSnapshotEntry
+// errors.
+func SaveEnv(w io.Writer, env *Env) (skipped []error, err error) {
+	var snap snapshot
+	for _, name := range sortedNames(env.Vars) {
+		v := env.Vars[name].Elem()
+		if entry, err := saveValue(name, v, env); err != nil {
+			skipped = append(skipped, ErrSnapshotEntry{name, err})
+		} else {
+			snap.Vars = append(snap.Vars, entry)
+		}
+	}
+	for _, name := range sortedNames(env.Consts) {
+		c := env.Consts[name]
+		var entry snapshotEntry
+		var err error
+		if n, ok := c.Interface().(*ConstNumber); ok {
+			entry, err = saveUntyped(name, n)
+		} else {
+			entry, err = saveValue(name, c, env)
+		}
+		if err != nil {
+			skipped = append(skipped, ErrSnapshotEntry{name, err})
+		} else {
+			snap.Consts = append(snap.Consts, entry)
+		}
+	}
+	return skipped, gob.NewEncoder(w).Encode(&snap)
+}
+
+// LoadEnv reads a snapshot written by SaveEnv from r into env, replacing
+// any bindings of the same names. Type names are resolved in env, so any
+// types registered when the snapshot was saved must be registered again.
+// Entries which cannot be restored are reported in skipped as
+// ErrSnapshotEntry errors; the rest are still restored.
+func LoadEnv(r io.Reader, env *Env) (skipped []error, err error) {
+	var snap snapshot
+	if err := gob.NewDecoder(r).Decode(&snap); err != nil {
+		return nil, err
+	}
+	for _, entry := range snap.Vars {
+		if v, err := loadValue(entry, env); err != nil {
+			skipped = append(skipped, ErrSnapshotEntry{entry.Name, err})
+		} else {
+			envMu.Lock()
+			delete(env.Consts, entry.Name)
+			delete(env.Funcs, entry.Name)
+			env.Vars[entry.Name] = v
+			envMu.Unlock()
+		}
+	}
+	for _, entry := range snap.Consts {
+		var c reflect.Value
+		var err error
+		if entry.Type == "untyped" {
+			c, err = loadUntyped(entry)
+		} else if c, err = loadValue(entry, env); err == nil {
+			c = c.Elem()
+		}
+		if err != nil {
+			skipped = append(skipped, ErrSnapshotEntry{entry.Name, err})
+		} else {
+			envMu.Lock()
+			delete(env.Vars, entry.Name)
+			delete(env.Funcs, entry.Name)
+			env.Consts[entry.Name] = c
+			envMu.Unlock()
+		}
+	}
+	return skipped, nil
+}
+
+func saveValue(name string, v reflect.Value, env *Env) (snapshotEntry, error) {
+	entry := snapshotEntry{Name: name}
+	t := v.Type()
+	if !isSerializable(t, map[reflect.Type]bool{}) {
+		return entry, fmt.Errorf("values of type %v cannot be saved", t)
+	}
+	typeName, ok := typeExprOf(t, env)
+	if !ok {
+		return entry, fmt.Errorf("type %v is not registered in the Env", t)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).EncodeValue(v); err != nil {
+		return entry, err
+	}
+	entry.Type = typeName
+	entry.Data = buf.Bytes()
+	return entry, nil
+}
+
+// Returns a pointer to the restored value
+func loadValue(entry snapshotEntry, env *Env) (reflect.Value, error) {
+	t, err := resolveTypeExpr(entry.Type, env)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	ptr := reflect.New(t)
+	if err := gob.NewDecoder(bytes.NewReader(entry.Data)).DecodeValue(ptr); err != nil {
+		return reflect.Value{}, err
+	}
+	return ptr, nil
+}
+
+// Untyped constants are saved as their numeric value along with their kind
+type untypedConst struct {
+	Kind   string
+	Re, Im untypedPart
+}
+
+// One of Rat or Float is set, whichever go/constant used for the part
+type untypedPart struct {
+	Rat   *big.Rat
+	Float *big.Float
+}
+
+var untypedKinds = map[string]ConstType{
+	"int":     ConstInt,
+	"rune":    ConstRune,
+	"float":   ConstFloat,
+	"complex": ConstComplex,
+}
+
+func saveUntyped(name string, n *ConstNumber) (snapshotEntry, error) {
+	entry := snapshotEntry{Name: name, Type: "untyped"}
+	u := untypedConst{
+		Re: makeUntypedPart(constant.Real(n.Value)),
+		Im: makeUntypedPart(constant.Imag(n.Value)),
+	}
+	for kind, ct := range untypedKinds {
+		if ct == n.Type {
+			u.Kind = kind
+		}
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&u); err != nil {
+		return entry, err
+	}
+	entry.Data = buf.Bytes()
+	return entry, nil
+}
+
+func loadUntyped(entry snapshotEntry) (reflect.Value, error) {
+	var u untypedConst
+	if err := gob.NewDecoder(bytes.NewReader(entry.Data)).Decode(&u); err != nil {
+		return reflect.Value{}, err
+	}
+	ct, ok := untypedKinds[u.Kind]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("unknown untyped constant kind %q", u.Kind)
+	}
+	v := u.Re.value()
+	if ct.IsIntegral() {
+		v = constant.ToInt(v)
+	} else if im := u.Im.value(); constant.Sign(im) != 0 {
+		v = constant.BinaryOp(v, token.ADD, constant.MakeImag(im))
+	}
+	return reflect.ValueOf(&ConstNumber{v, ct}), nil
+}
+
+func makeUntypedPart(x constant.Value) untypedPart {
+	switch x := constant.Val(constant.ToFloat(x)).(type) {
+	case *big.Rat:
+		return untypedPart{Rat: x}
+	case *big.Float:
+		return untypedPart{Float: x}
+	}
+	panic("eval: impossible constant " + x.String())
+}
+
+func (p untypedPart) value() constant.Value {
+	if p.Float != nil {
+		return constant.Make(p.Float)
+	}
+	if p.Rat == nil {
+		return constant.MakeInt64(0)
+	}
+	return constant.Make(p.Rat)
+}
+
+// Can gob encode values of type t without loss. gob silently drops
+// unexported, func and chan struct fields, so structs must have none.
+// seen holds the struct types already being checked, which may recur.
+func isSerializable(t reflect.Type, seen map[reflect.Type]bool) bool {
+	switch t.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return false
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return isSerializable(t.Elem(), seen)
+	case reflect.Map:
+		return isSerializable(t.Key(), seen) && isSerializable(t.Elem(), seen)
+	case reflect.Struct:
+		if seen[t] {
+			return true
+		}
+		seen[t] = true
+		for i := 0; i < t.NumField(); i += 1 {
+			field := t.Field(i)
+			if !field.IsExported() || !isSerializable(field.Type, seen) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Write t as a type expression which resolves back to t in env. Named types
+// must be registered in env, its Pkgs or the universe.
+func typeExprOf(t reflect.Type, env *Env) (string, bool) {
+	var candidates []string
+	for scope := env; scope != nil; scope = scope.Parent {
+		for name, rt := range scope.Types {
+			if rt == t {
+				candidates = append(candidates, name)
+			}
+		}
+		for pkgName, pkg := range scope.Pkgs {
+			for name, rt := range pkg.Types {
+				if rt == t {
+					candidates = append(candidates, pkgName+"."+name)
+				}
+			}
+		}
+	}
+	for name, rt := range universe.Types {
+		if rt == t {
+			candidates = append(candidates, name)
+		}
+	}
+	if t == emptyInterface {
+		candidates = append(candidates, "interface{}")
+	}
+	sort.Strings(candidates)
+	for _, name := range candidates {
+		// The name may be shadowed by an inner scope
+		if rt, err := resolveTypeExpr(name, env); err == nil && rt == t {
+			return name, true
+		}
+	}
+
+	if t.Name() != "" {
+		return "", false
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		if elem, ok := typeExprOf(t.Elem(), env); ok {
+			return "*" + elem, true
+		}
+	case reflect.Slice:
+		if elem, ok := typeExprOf(t.Elem(), env); ok {
+			return "[]" + elem, true
+		}
+	case reflect.Array:
+		if elem, ok := typeExprOf(t.Elem(), env); ok {
+			return "[" + strconv.Itoa(t.Len()) + "]" + elem, true
+		}
+	case reflect.Map:
+		key, keyOk := typeExprOf(t.Key(), env)
+		elem, elemOk := typeExprOf(t.Elem(), env)
+		if keyOk && elemOk {
+			return "map[" + key + "]" + elem, true
+		}
+	}
+	return "", false
+}
+
+func resolveTypeExpr(typeExpr string, env *Env) (reflect.Type, error) {
+	expr, err := parser.ParseExpr(typeExpr)
+	if err != nil {
+		return nil, err
+	}
+	_, t, isType, errs := checkType(&Ctx{Input: typeExpr}, expr, env)
+	if !isType || errs != nil {
+		return nil, fmt.Errorf("cannot resolve type %s", typeExpr)
+	}
+	return t, nil
+}
+
+func sortedNames(m map[string]reflect.Value) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,127 @@
+package eval
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+type snapshotPoint struct {
+	X, Y int
+}
+
+func TestSaveLoadEnv(t *testing.T) {
+	env := NewEnv()
+	env.SetType("Point", (*snapshotPoint)(nil))
+	i := 3
+	s := []string{"a", "b"}
+	m := map[string]*snapshotPoint{"p": {1, 2}}
+	var e interface{} = 1.5
+	ch := make(chan int)
+	env.SetVar("i", &i)
+	env.SetVar("s", &s)
+	env.SetVar("m", &m)
+	env.SetVar("e", &e)
+	env.SetVar("ch", &ch)
+	env.SetConst("K", int8(4))
+	env.SetConst("Big", new(big.Int).Lsh(big.NewInt(1), 100))
+
+	var buf bytes.Buffer
+	skipped, err := SaveEnv(&buf, env)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(skipped) != 1 || skipped[0].(ErrSnapshotEntry).Name != "ch" {
+		t.Fatalf("expected only ch to be skipped, got %v", skipped)
+	}
+
+	restored := NewEnv()
+	restored.SetType("Point", (*snapshotPoint)(nil))
+	if skipped, err := LoadEnv(&buf, restored); err != nil {
+		t.Fatal(err)
+	} else if skipped != nil {
+		t.Fatalf("unexpected skipped entries %v", skipped)
+	}
+
+	expectResult(t, "i", restored, 3)
+	expectResult(t, "s[1]", restored, "b")
+	expectResult(t, "m[\"p\"].Y", restored, 2)
+	expectResult(t, "e", restored, interface{}(1.5))
+	expectConst(t, "K", restored, int8(4), reflect.TypeOf(int8(0)))
+	expectConst(t, "Big / Big * 4", restored, NewConstInt64(4), ConstInt)
+}
+
+func TestLoadEnvUnregisteredType(t *testing.T) {
+	env := NewEnv()
+	env.SetType("Point", (*snapshotPoint)(nil))
+	p := snapshotPoint{1, 2}
+	i := 1
+	env.SetVar("p", &p)
+	env.SetVar("i", &i)
+
+	var buf bytes.Buffer
+	if _, err := SaveEnv(&buf, env); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewEnv()
+	skipped, err := LoadEnv(&buf, restored)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(skipped) != 1 || skipped[0].Error() != "p: cannot resolve type Point" {
+		t.Fatalf("expected p to be skipped, got %v", skipped)
+	}
+	expectResult(t, "i", restored, 1)
+}
+
+type snapshotPrivate struct {
+	X, y int
+}
+
+type snapshotHandler struct {
+	Name string
+	F    func()
+}
+
+type snapshotList struct {
+	V    int
+	Next *snapshotList
+}
+
+func TestSaveEnvStructsAndArrays(t *testing.T) {
+	env := NewEnv()
+	env.SetType("Private", (*snapshotPrivate)(nil))
+	env.SetType("Handler", (*snapshotHandler)(nil))
+	env.SetType("List", (*snapshotList)(nil))
+	p := snapshotPrivate{1, 2}
+	h := snapshotHandler{"h", func() {}}
+	l := snapshotList{1, &snapshotList{2, nil}}
+	a := [3]int{1, 2, 3}
+	env.SetVar("p", &p)
+	env.SetVar("h", &h)
+	env.SetVar("l", &l)
+	env.SetVar("a", &a)
+
+	var buf bytes.Buffer
+	skipped, err := SaveEnv(&buf, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, err := range skipped {
+		names = append(names, err.(ErrSnapshotEntry).Name)
+	}
+	if !reflect.DeepEqual(names, []string{"h", "p"}) {
+		t.Fatalf("expected h and p to be skipped, got %v", skipped)
+	}
+
+	restored := NewEnv()
+	restored.SetType("List", (*snapshotList)(nil))
+	if skipped, err := LoadEnv(&buf, restored); err != nil {
+		t.Fatal(err)
+	} else if skipped != nil {
+		t.Fatalf("unexpected skipped entries %v", skipped)
+	}
+	expectResult(t, "a", restored, a)
+	expectResult(t, "l.Next.V", restored, 2)
+}
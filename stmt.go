@@ -0,0 +1,137 @@
+package eval
+
+import (
+	"reflect"
+
+	"go/ast"
+	"go/token"
+)
+
+// Annotated ast.Stmt nodes, produced by checking the body of a func
+// declaration. Nested statement lists are checked in place, so the
+// lists of a BlockStmt, IfStmt etc. hold annotated nodes.
+
+type BlockStmt struct {
+	*ast.BlockStmt
+}
+
+type ExprStmt struct {
+	*ast.ExprStmt
+}
+
+type DeclStmt struct {
+	*ast.DeclStmt
+
+	// The checked vars of each var spec. Const and type specs are bound
+	// at check time and need no evaluation.
+	vars [][]checkedVar
+}
+
+// Assignments, including op= and ++/--, which are checked as x = x op y
+type AssignStmt struct {
+	*ast.AssignStmt
+
+	// Checked lhs, nil for _ and for variables declared by :=
+	lhs []Expr
+
+	// The type of each lhs, nil for _
+	types []reflect.Type
+
+	vars    []checkedVar
+	commaOk bool
+
+	// x op y for op= assignments
+	opExpr Expr
+}
+
+type ReturnStmt struct {
+	*ast.ReturnStmt
+	vars []checkedVar
+}
+
+type IfStmt struct {
+	*ast.IfStmt
+}
+
+type ForStmt struct {
+	*ast.ForStmt
+	label string
+}
+
+type RangeStmt struct {
+	*ast.RangeStmt
+	label string
+
+	// Type of the range key and value
+	keyT, valueT reflect.Type
+
+	// Checked key and value of a range = loop, nil for _
+	key, value Expr
+}
+
+type SwitchStmt struct {
+	*ast.SwitchStmt
+	label string
+
+	// Type of the tag, nil for a switch without one
+	tagT reflect.Type
+
+	clauses []*caseClause
+}
+
+type caseClause struct {
+	*ast.CaseClause
+
+	// The case values, and the type in which each is compared to the tag
+	values []Expr
+	types  []reflect.Type
+
+	// Statements of the clause, excluding a final fallthrough
+	body         []ast.Stmt
+	fallsThrough bool
+}
+
+type SendStmt struct {
+	*ast.SendStmt
+
+	// Type of the channel's elements
+	elemT reflect.Type
+}
+
+type SelectStmt struct {
+	*ast.SelectStmt
+	label string
+
+	clauses []*commClause
+}
+
+type commClause struct {
+	*ast.CommClause
+
+	// The checked send, or receive from the channel recv.X, of the clause.
+	// Both are nil for the default clause. A receive which assigns its
+	// result leaves the checked *AssignStmt in Comm.
+	send *SendStmt
+	recv *UnaryExpr
+}
+
+type DeferStmt struct {
+	*ast.DeferStmt
+	call *CallExpr
+}
+
+type GoStmt struct {
+	*ast.GoStmt
+	call *CallExpr
+}
+
+type BranchStmt struct {
+	*ast.BranchStmt
+}
+
+// Control flow out of a statement list: a break, continue or return. tok is
+// token.ILLEGAL if the list ran to completion.
+type branch struct {
+	tok   token.Token
+	label string
+}
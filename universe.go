@@ -0,0 +1,53 @@
+package eval
+
+import (
+	"reflect"
+)
+
+// The universe scope holds Go's predeclared identifiers. It sits at the
+// root of identifier lookup, so that anything bound in an Env shadows it,
+// just as declarations shadow predeclared identifiers in Go.
+var universe = &Env{
+	Name: "universe",
+	Vars: map[string]reflect.Value{},
+	Consts: map[string]reflect.Value{
+		"true":  reflect.ValueOf(true),
+		"false": reflect.ValueOf(false),
+		"nil":   reflect.ValueOf(UntypedNil{}),
+	},
+	Funcs: builtinFuncs,
+	Types: builtinTypes,
+	Pkgs:  map[string]Pkg{},
+}
+
+// Is name bound directly in env, ignoring its Parent scopes
+func (env *Env) declares(name string) bool {
+	if _, ok := env.Vars[name]; ok {
+		return true
+	} else if _, ok := env.Consts[name]; ok {
+		return true
+	} else if _, ok := env.Funcs[name]; ok {
+		return true
+	} else if _, ok := env.Types[name]; ok {
+		return true
+	} else if _, ok := env.Pkgs[name]; ok {
+		return true
+	} else if _, _, ok := env.lookupReceiver(name); ok {
+		return true
+	}
+	return false
+}
+
+// Does name refer to a builtin function in env
+func isBuiltinFunc(name string, env *Env) bool {
+	_, ok := universe.Funcs[name]
+	scope, _ := env.lookupScope(name)
+	return ok && scope == nil
+}
+
+// Does name refer to a predeclared type in env
+func lookupUniverseType(name string, env *Env) (reflect.Type, bool) {
+	t, ok := universe.Types[name]
+	scope, _ := env.lookupScope(name)
+	return t, ok && scope == nil
+}
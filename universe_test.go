@@ -0,0 +1,61 @@
+package eval
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUniverseShadowBuiltinFunc(t *testing.T) {
+	env := makeEnv()
+	env.Funcs["len"] = reflect.ValueOf(func(x int) int { return x * 2 })
+	expectResult(t, "len(4)", env, 8)
+
+	length := 3
+	env = makeEnv()
+	env.Vars["len"] = reflect.ValueOf(&length)
+	expectResult(t, "len + 1", env, 4)
+}
+
+func TestUniverseShadowConsts(t *testing.T) {
+	env := makeEnv()
+	yes := 1
+	env.Vars["true"] = reflect.ValueOf(&yes)
+	expectResult(t, "true + 1", env, 2)
+	expectResult(t, "false", env, false)
+}
+
+func TestUniverseShadowTypes(t *testing.T) {
+	type error struct{ A int }
+	env := makeEnv()
+	env.Types["error"] = reflect.TypeOf(error{})
+	expectType(t, "error{}", env, reflect.TypeOf(error{}))
+
+	i := 5
+	env = makeEnv()
+	env.Vars["int"] = reflect.ValueOf(&i)
+	expectResult(t, "int * 2", env, 10)
+	expectCheckError(t, "int(2)", env, "cannot call non-function int (type int)")
+}
+
+func TestUniverseErrorType(t *testing.T) {
+	env := makeEnv()
+	err := errors.New("e")
+	env.Vars["err"] = reflect.ValueOf(&err)
+	expectType(t, "error(nil)", env, reflect.TypeOf(new(error)).Elem())
+	expectResult(t, "error(err).Error()", env, "e")
+	expectResult(t, "err != nil", env, true)
+}
+
+func TestUniverseBuiltinNotCalled(t *testing.T) {
+	env := makeEnv()
+	expectCheckError(t, "len", env, "use of builtin len not in function call")
+	expectCheckError(t, "1 + cap", env, "use of builtin cap not in function call")
+}
+
+func TestUniversePackageMembers(t *testing.T) {
+	env := makeEnv()
+	env.Pkgs["p"] = makeEnv()
+	expectCheckError(t, "p.true", env, "undefined: p.true")
+	expectCheckError(t, "p.len(nil)", env, "undefined: p.len")
+}
@@ -10,26 +10,9 @@ import (
 	"go/token"
 )
 
-// Equivalent of reflect.New, but unwraps internal Types into their original reflect.Type
-func hackedNew(t reflect.Type) reflect.Value {
-	return reflect.New(unhackType(t))
-}
-
-// Get the underlying reflect.Type a hacked type
-func unhackType(t reflect.Type) reflect.Type {
-	switch tt := t.(type) {
-	case Rune:
-		return tt.Type
-	case Byte:
-		return tt.Type
-	default:
-		return t
-	}
-}
-
 // Determine if type from is assignable to type to. From and To must not be ConstTypes
 func typeAssignableTo(from, to reflect.Type) bool {
-	return from.AssignableTo(unhackType(to))
+	return from.AssignableTo(to)
 }
 
 // exprAssignableTo(CheckExpr(expr), t), but errors are accumulated and a
@@ -389,7 +372,7 @@ func isAddressable(expr Expr) bool {
 	expr = skipSuperfluousParens(expr)
 	switch n := expr.(type) {
 	case *Ident:
-		return n.source == envVar
+		return n.source == envVar || n.source == envField
 	case *StarExpr:
 		return true
 	case *IndexExpr:
@@ -417,14 +400,18 @@ func isAddressable(expr Expr) bool {
 }
 
 func isAddressableOrCompositeLit(expr Expr) bool {
-	expr = skipSuperfluousParens(expr)
-	if _, ok := expr.(*CompositeLit); ok {
+	if isCompositeLit(expr) {
 		return true
 	} else {
 		return isAddressable(expr)
 	}
 }
 
+func isCompositeLit(expr Expr) bool {
+	_, ok := skipSuperfluousParens(expr).(*CompositeLit)
+	return ok
+}
+
 func isStaticTypeComparable(t reflect.Type) bool {
 	switch t.Kind() {
 	case reflect.Slice, reflect.Map, reflect.Func:
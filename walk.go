@@ -1,81 +1,149 @@
 package eval
 
 import (
+	"reflect"
+
 	"go/ast"
 )
 
-type exprVisitor interface {
-	visit(expr Expr) bool
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of expr with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(expr Expr) (w Visitor)
 }
 
-func walk(expr ast.Expr, visitor exprVisitor) {
-	if expr == nil {
+// Walk traverses a checked Expr tree in depth-first order. It starts by
+// calling v.Visit(expr); expr must not be nil. If the visitor w returned by
+// v.Visit(expr) is not nil, Walk is invoked recursively with visitor w for
+// each of the non-nil children of expr, followed by a call of w.Visit(nil).
+//
+// Only children which were type checked are visited. Struct field names
+// in composite literals, the bodies of func literals and sub expressions
+// left unchecked after an error are plain ast.Expr nodes and are skipped.
+func Walk(v Visitor, expr Expr) {
+	if v = v.Visit(expr); v == nil {
 		return
 	}
+	if sel, ok := expr.(*SelectorExpr); ok {
+		// Sel shadows the *ast.Ident of the embedded ast.SelectorExpr
+		if x, ok := checkedChild(sel.X); ok {
+			Walk(v, x)
+		}
+		if sel.Sel != nil {
+			Walk(v, sel.Sel)
+		}
+	} else {
+		for _, child := range children(expr) {
+			if x, ok := checkedChild(*child); ok {
+				Walk(v, x)
+			}
+		}
+	}
+	v.Visit(nil)
+}
+
+type inspector func(Expr) bool
+
+func (f inspector) Visit(expr Expr) Visitor {
+	if f(expr) {
+		return f
+	}
+	return nil
+}
+
+// InspectExpr traverses a checked Expr tree in depth-first order, in the
+// same manner as Walk. It starts by calling f(expr); expr must not be nil.
+// If f returns true, InspectExpr invokes f recursively for each of the
+// non-nil children of expr, followed by a call of f(nil).
+func InspectExpr(expr Expr, f func(Expr) bool) {
+	Walk(inspector(f), expr)
+}
+
+// Rewrite traverses a checked Expr tree in depth-first order, visiting the
+// same children as Walk, and replaces each node with the result of f. The
+// children of a node are rewritten before the node itself is passed to f,
+// and the result of f on the root is returned.
+//
+// Nodes are rewritten in place, so the tree given to Rewrite is modified.
+// f is responsible for returning nodes with sensible annotations; a node
+// which is returned unchanged keeps its type and constant value, which
+// may no longer agree with rewritten children. The Sel of a SelectorExpr
+// may only be replaced by another *Ident.
+func Rewrite(expr Expr, f func(Expr) Expr) Expr {
+	if sel, ok := expr.(*SelectorExpr); ok {
+		if x, ok := checkedChild(sel.X); ok {
+			sel.X = Rewrite(x, f)
+		}
+		if sel.Sel != nil {
+			if ident, ok := Rewrite(sel.Sel, f).(*Ident); ok {
+				sel.Sel = ident
+				sel.SelectorExpr.Sel = ident.Ident
+			} else {
+				panic("eval: Rewrite replaced SelectorExpr.Sel with a non *Ident")
+			}
+		}
+	} else {
+		for _, child := range children(expr) {
+			if x, ok := checkedChild(*child); ok {
+				*child = Rewrite(x, f)
+			}
+		}
+	}
+	return f(expr)
+}
+
+// Returns pointers to the child expressions of expr in source order, so
+// that they may be both visited and replaced. SelectorExpr is handled by
+// the callers, as its Sel is not an ast.Expr.
+func children(expr Expr) []*ast.Expr {
 	switch expr := expr.(type) {
-	case *BadExpr:
-		visitor.visit(expr)
-	case *Ident:
-		visitor.visit(expr)
 	case *Ellipsis:
-		visitor.visit(expr)
-	case *BasicLit:
-		visitor.visit(expr)
-	case *FuncLit:
-		visitor.visit(expr)
+		return []*ast.Expr{&expr.Elt}
 	case *CompositeLit:
-		if visitor.visit(expr) {
-			for _, elt := range expr.Elts {
-				walk(elt, visitor)
-			}
+		exprs := []*ast.Expr{&expr.Type}
+		for i := range expr.Elts {
+			exprs = append(exprs, &expr.Elts[i])
 		}
+		return exprs
 	case *ParenExpr:
-		if visitor.visit(expr) {
-			walk(expr.X, visitor)
-		}
-	case *SelectorExpr:
-		if visitor.visit(expr) {
-			walk(expr.X, visitor)
-		}
+		return []*ast.Expr{&expr.X}
 	case *IndexExpr:
-		if visitor.visit(expr) {
-			walk(expr.Index, visitor)
-			walk(expr.X, visitor)
-		}
+		return []*ast.Expr{&expr.X, &expr.Index}
 	case *SliceExpr:
-		if visitor.visit(expr) {
-			walk(expr.Low, visitor)
-			walk(expr.High, visitor)
-			// TODO[crc] go 1.2 introduces the [::] notation. Add after upgrade
-			// walk(expr.Max, visitor)
-		}
+		return []*ast.Expr{&expr.X, &expr.Low, &expr.High, &expr.Max}
 	case *TypeAssertExpr:
-		if visitor.visit(expr) {
-			walk(expr.X, visitor)
-		}
+		return []*ast.Expr{&expr.X, &expr.Type}
 	case *CallExpr:
-		if visitor.visit(expr) {
-			for _, arg := range expr.Args {
-				walk(arg, visitor)
-			}
+		exprs := []*ast.Expr{&expr.Fun}
+		for i := range expr.Args {
+			exprs = append(exprs, &expr.Args[i])
 		}
+		return exprs
 	case *StarExpr:
-		if visitor.visit(expr) {
-			walk(expr.X, visitor)
-		}
+		return []*ast.Expr{&expr.X}
 	case *UnaryExpr:
-		if visitor.visit(expr) {
-			walk(expr.X, visitor)
-		}
+		return []*ast.Expr{&expr.X}
 	case *BinaryExpr:
-		if visitor.visit(expr) {
-			walk(expr.X, visitor)
-			walk(expr.Y, visitor)
-		}
+		return []*ast.Expr{&expr.X, &expr.Y}
 	case *KeyValueExpr:
-		if visitor.visit(expr) {
-			walk(expr.Key, visitor)
-			walk(expr.Value, visitor)
-		}
+		return []*ast.Expr{&expr.Key, &expr.Value}
+	case *ArrayType:
+		return []*ast.Expr{&expr.Len, &expr.Elt}
+	case *MapType:
+		return []*ast.Expr{&expr.Key, &expr.Value}
+	case *ChanType:
+		return []*ast.Expr{&expr.Value}
+	}
+	// BadExpr, Ident, BasicLit, FuncLit, StructType, FuncType and
+	// InterfaceType have no checked children
+	return nil
+}
+
+// Returns x as an Expr if it is a non-nil, type checked node
+func checkedChild(x ast.Expr) (Expr, bool) {
+	if e, ok := x.(Expr); ok && !reflect.ValueOf(e).IsNil() {
+		return e, true
 	}
+	return nil, false
 }
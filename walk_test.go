@@ -0,0 +1,91 @@
+package eval
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInspectExprIdents(t *testing.T) {
+	env := makeEnv()
+	s := []int{1, 2, 3}
+	i := 1
+	m := map[string][]int{"k": {4}}
+	env.Vars["s"] = reflect.ValueOf(&s)
+	env.Vars["i"] = reflect.ValueOf(&i)
+	env.Vars["m"] = reflect.ValueOf(&m)
+	env.Funcs["f"] = reflect.ValueOf(func(int) int { return 0 })
+	env.Types["T"] = reflect.TypeOf(0)
+
+	expr := getCheckedExpr(t, `append(s[i:f(i)], []int{T(i), m["k"][0]}...)`, env)
+	var idents []string
+	InspectExpr(expr, func(e Expr) bool {
+		if ident, ok := e.(*Ident); ok {
+			idents = append(idents, ident.Name)
+		}
+		return true
+	})
+	expected := []string{"append", "s", "i", "f", "i", "int", "T", "i", "m"}
+	if !reflect.DeepEqual(idents, expected) {
+		t.Fatalf("Visited idents %v, expected %v", idents, expected)
+	}
+}
+
+type countingVisitor struct {
+	enter, leave int
+}
+
+func (v *countingVisitor) Visit(expr Expr) Visitor {
+	if expr == nil {
+		v.leave += 1
+	} else {
+		v.enter += 1
+	}
+	return v
+}
+
+func TestWalkPrunes(t *testing.T) {
+	env := makeEnv()
+	x := 1
+	env.Vars["x"] = reflect.ValueOf(&x)
+
+	expr := getCheckedExpr(t, "(x + 1) * -x", env)
+	v := new(countingVisitor)
+	Walk(v, expr)
+	if v.enter != 7 || v.leave != 7 {
+		t.Fatalf("Walk entered %d and left %d nodes, expected 7 and 7", v.enter, v.leave)
+	}
+
+	var visited int
+	InspectExpr(expr, func(e Expr) bool {
+		if e != nil {
+			visited += 1
+		}
+		_, isParen := e.(*ParenExpr)
+		return !isParen
+	})
+	if visited != 4 {
+		t.Fatalf("InspectExpr visited %d nodes, expected 4", visited)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	env := makeEnv()
+	x, y := 1, 10
+	env.Vars["x"] = reflect.ValueOf(&x)
+	env.Vars["y"] = reflect.ValueOf(&y)
+
+	expr := getCheckedExpr(t, "x * 2 + x", env)
+	replacement := getCheckedExpr(t, "y", env)
+	expr = Rewrite(expr, func(e Expr) Expr {
+		if ident, ok := e.(*Ident); ok && ident.Name == "x" {
+			return replacement
+		}
+		return e
+	})
+
+	if results, _, err := EvalExpr(&Ctx{}, expr, env); err != nil {
+		t.Fatal(err)
+	} else if r := (*results)[0].Interface(); r != 30 {
+		t.Fatalf("Rewritten expression yielded %v, expected 30", r)
+	}
+}